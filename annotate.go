@@ -0,0 +1,182 @@
+package sandblast
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"io"
+	"strings"
+)
+
+const annotateStyle = `
+.sandblast-kept { background-color: #c8f7c5 !important; }
+.sandblast-dropped { background-color: #f7c5c5 !important; text-decoration: line-through; }
+.sandblast-links { background-color: #f7e0b0 !important; text-decoration: line-through; }
+.sandblast-header { background-color: #b0d0f7 !important; }
+.sandblast-special { outline: 3px solid #a070d0 !important; }
+.sandblast-dropped.sandblast-special { outline-color: #d07070 !important; }
+#sandblast-legend { position: fixed; top: 0; right: 0; z-index: 2147483647; background: white; color: black; border: 1px solid black; padding: 4px; font: 12px sans-serif; }
+#sandblast-legend span { padding: 0 4px; }
+`
+
+const annotateLegend = `<span class="sandblast-kept">kept</span><span class="sandblast-header">header</span><span class="sandblast-dropped">dropped</span><span class="sandblast-links">link list / link blob</span><span class="sandblast-special">figure / media</span>`
+
+// Writes to w the HTML of the extracted document with the blocks of text highlighted according to the decisions of the cleaning stage,
+// the reason for each decision is reported in a tooltip. Scripts, embedded frames and objects, event handler attributes
+// and javascript: URLs are removed.
+// The result must be obtained by extracting with Explain.
+func (r *Result) WriteAnnotatedHTML(w io.Writer) error {
+	if len(r.roots) == 0 || r.Log == nil {
+		return fmt.Errorf("Annotated HTML requires extracting with Explain")
+	}
+
+	copies := map[*html.Node]*html.Node{}
+	roots := make([]*html.Node, len(r.roots))
+	for i := range r.roots {
+		roots[i] = copyNode(r.roots[i], copies)
+	}
+
+	for _, d := range r.Log {
+		if d.Stage != "clean" || d.el == nil {
+			continue
+		}
+
+		class := "sandblast-kept"
+		switch {
+		case d.Kind == "linklist" || d.Kind == "linkblob":
+			class = "sandblast-links"
+		case d.Dropped:
+			class = "sandblast-dropped"
+		case d.Kind == "header":
+			class = "sandblast-header"
+		}
+		title := d.Kind + ", kept: " + d.Reason
+		if d.Dropped {
+			title = d.Kind + ", dropped: " + d.Reason
+		}
+
+		for _, source := range d.el.allSources() {
+			node := copies[source]
+			if node == nil || node.Parent == nil {
+				continue
+			}
+			if node.Type == html.ElementNode {
+				setAttribute(node, "class", strings.TrimSpace(getAttribute(node, "class")+" "+class+" sandblast-special"))
+				setAttribute(node, "title", title)
+				continue
+			}
+			span := &html.Node{Type: html.ElementNode, DataAtom: atom.Span, Data: "span"}
+			span.Attr = []html.Attribute{{Key: "class", Val: class}, {Key: "title", Val: title}}
+			node.Parent.InsertBefore(span, node)
+			node.Parent.RemoveChild(node)
+			span.AppendChild(node)
+		}
+	}
+
+	style := &html.Node{Type: html.ElementNode, DataAtom: atom.Style, Data: "style"}
+	style.AppendChild(&html.Node{Type: html.TextNode, Data: annotateStyle})
+	legend := &html.Node{Type: html.ElementNode, DataAtom: atom.Div, Data: "div", Attr: []html.Attribute{{Key: "id", Val: "sandblast-legend"}}}
+	if nodes, err := html.ParseFragment(strings.NewReader(annotateLegend), legend); err == nil {
+		for _, node := range nodes {
+			legend.AppendChild(node)
+		}
+	}
+
+	// style and legend go inside head and body if they exist, otherwise they are written before the document
+	before := []*html.Node{}
+	if head := findSelfOrElement(roots[0], "head"); head != nil {
+		head.AppendChild(style)
+	} else {
+		before = append(before, style)
+	}
+	if body := findSelfOrElement(roots[0], "body"); body != nil {
+		body.InsertBefore(legend, body.FirstChild)
+	} else {
+		before = append(before, legend)
+	}
+
+	for _, node := range append(before, roots...) {
+		removeActiveContent(node)
+		if err := html.Render(w, node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the sources of e and all its descendants
+func (e *element) allSources() []*html.Node {
+	var r []*html.Node
+	walkElements(e, func(e *element) {
+		r = append(r, e.sources...)
+	})
+	return r
+}
+
+// Returns a deep copy of node, adds the copied nodes to copies
+func copyNode(node *html.Node, copies map[*html.Node]*html.Node) *html.Node {
+	shallow := func(node *html.Node) *html.Node {
+		r := &html.Node{Type: node.Type, DataAtom: node.DataAtom, Data: node.Data, Namespace: node.Namespace}
+		r.Attr = make([]html.Attribute, len(node.Attr))
+		copy(r.Attr, node.Attr)
+		copies[node] = r
+		return r
+	}
+	r := shallow(node)
+	walkNodes(node, func(child *html.Node) bool {
+		// the parent is copied before its children
+		copies[child.Parent].AppendChild(shallow(child))
+		return true
+	})
+	return r
+}
+
+// Elements removed from the annotated HTML because they run code or load other documents
+var activeElements = map[string]bool{"script": true, "iframe": true, "frame": true, "object": true, "embed": true, "applet": true}
+
+// Removes the descendants of node that run code or load other documents (see activeElements), refresh meta headers,
+// event handler attributes and javascript: URLs
+func removeActiveContent(node *html.Node) {
+	removeActiveAttributes(node)
+	walkNodes(node, func(child *html.Node) bool {
+		if child.Type != html.ElementNode {
+			return true
+		}
+		name := strings.ToLower(child.Data)
+		if activeElements[name] || (name == "meta" && strings.EqualFold(strings.TrimSpace(getAttribute(child, "http-equiv")), "refresh")) {
+			child.Parent.RemoveChild(child)
+			return false
+		}
+		removeActiveAttributes(child)
+		return true
+	})
+}
+
+func removeActiveAttributes(node *html.Node) {
+	attrs := node.Attr[:0]
+	for _, attr := range node.Attr {
+		key := strings.ToLower(attr.Key)
+		// browsers ignore white space and control characters in URLs
+		val := strings.ToLower(strings.Map(func(ch rune) rune {
+			if ch <= ' ' {
+				return -1
+			}
+			return ch
+		}, attr.Val))
+		if strings.HasPrefix(key, "on") || strings.HasPrefix(val, "javascript:") || strings.HasPrefix(val, "vbscript:") {
+			continue
+		}
+		attrs = append(attrs, attr)
+	}
+	node.Attr = attrs
+}
+
+func setAttribute(node *html.Node, name, val string) {
+	for i := range node.Attr {
+		if strings.ToLower(node.Attr[i].Key) == name {
+			node.Attr[i].Val = val
+			return
+		}
+	}
+	node.Attr = append(node.Attr, html.Attribute{Key: name, Val: val})
+}
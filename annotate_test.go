@@ -0,0 +1,61 @@
+package sandblast
+
+import (
+	"bytes"
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestAnnotatedHTML(t *testing.T) {
+	const doc = `<html><head><title>Test</title><script>alert(1)</script><meta http-equiv="refresh" content="0; url=javascript:alert(2)"></head><body onload="alert(3)">
+<p>This is the first paragraph of the article, long enough to be kept as text.</p>
+<p>Short one</p>
+<p><a href=" JavaScript:alert(4)" onClick="alert(5)">link</a><img src="a.png" onerror="alert(6)"></p>
+<iframe src="https://example.com/alert7"></iframe><object data="alert8.swf"></object><embed src="alert9.swf">
+</body></html>`
+
+	node, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := ExtractResult(node, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.WriteAnnotatedHTML(&bytes.Buffer{}) == nil {
+		t.Errorf("Annotated HTML without Explain should fail")
+	}
+
+	r, err = ExtractResult(node, Explain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := &bytes.Buffer{}
+	if err := r.WriteAnnotatedHTML(out); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{
+		`<p><span class="sandblast-kept" title="textblock, kept: ok text">This is the first paragraph`,
+		`<p><span class="sandblast-dropped" title="textblock, dropped: textblock shorter than 15 characters">Short one</span></p>`,
+		`<div id="sandblast-legend">`,
+	} {
+		if strings.Index(out.String(), s) < 0 {
+			t.Errorf("Missing %q in annotated HTML:\n%s\n", s, out.String())
+		}
+	}
+	if strings.Index(out.String(), "alert") >= 0 {
+		t.Errorf("Active content not removed from annotated HTML:\n%s\n", out.String())
+	}
+	if strings.Index(out.String(), `<img src="a.png"/>`) < 0 {
+		t.Errorf("Image removed from annotated HTML:\n%s\n", out.String())
+	}
+
+	var orig bytes.Buffer
+	html.Render(&orig, node)
+	if strings.Index(orig.String(), "sandblast") >= 0 {
+		t.Errorf("Original document modified")
+	}
+}
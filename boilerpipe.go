@@ -0,0 +1,53 @@
+package sandblast
+
+type boilerpipeExtractor struct{}
+
+func (boilerpipeExtractor) Name() string { return "boilerpipe" }
+
+// Classifies blocks with the decision tree of boilerpipe's NumWordsRulesClassifier,
+// using the number of words and the link density of each block and of its neighbors
+func (boilerpipeExtractor) Classify(s *BlockSet) {
+	blocks := s.parent.childs
+	words := make([]int, len(blocks))
+	linkDensity := make([]float64, len(blocks))
+	for i, block := range blocks {
+		if block != nil {
+			words[i] = wordCount(blockText(block))
+			linkDensity[i] = float64(block.linkPart)
+		}
+	}
+	// blocks dropped before the classification are neighbors without words
+	at := func(v []int, i int) int {
+		if i < 0 || i >= len(v) {
+			return 0
+		}
+		return v[i]
+	}
+
+	for i := range blocks {
+		if s.Decided(i) || blocks[i].span > 0 {
+			continue
+		}
+		prevLinkDensity := 0.0
+		if i > 0 {
+			prevLinkDensity = linkDensity[i-1]
+		}
+		curr, prev, next := words[i], at(words, i-1), at(words, i+1)
+
+		content := false
+		switch {
+		case linkDensity[i] > 0.333333:
+			content = false
+		case prevLinkDensity <= 0.555556:
+			content = curr > 16 || next > 15 || prev > 4
+		default:
+			content = curr > 40 || next > 17
+		}
+
+		if content {
+			s.Keep(i, "content (%d words, link density %.2f)", curr, linkDensity[i])
+		} else {
+			s.Drop(i, "boilerplate (%d words, link density %.2f, previous %d words, next %d words)", curr, linkDensity[i], prev, next)
+		}
+	}
+}
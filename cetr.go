@@ -0,0 +1,169 @@
+package sandblast
+
+import (
+	"golang.org/x/net/html"
+	"math"
+	"sort"
+)
+
+const (
+	_CETR_SIGMA    = 1.0 // standard deviation of the gaussian smoothing
+	_CETR_ALPHA    = 3   // number of following blocks used to compute the derivative
+	_CETR_CLUSTERS = 3
+)
+
+type cetrExtractor struct{}
+
+func (cetrExtractor) Name() string { return "cetr" }
+
+// Classifies blocks like CETR: the text to tag ratio of each block is smoothed and paired with its derivative,
+// the pairs are clustered with k-means and the cluster closest to the origin is boilerplate.
+// Blocks already dropped (for example link lists) are part of the computation of the ratios.
+func (cetrExtractor) Classify(s *BlockSet) {
+	blocks := s.blocks
+	idx := []int{}
+	for i := range blocks {
+		if blocks[i] != nil && blocks[i].span <= 0 {
+			idx = append(idx, i)
+		}
+	}
+	if len(idx) < _CETR_CLUSTERS {
+		return
+	}
+
+	ttr := make([]float64, len(idx))
+	for k, i := range idx {
+		ttr[k] = float64(textLength(blockText(blocks[i]))) / float64(tagCount(blocks[i]))
+	}
+	ttr = gaussianSmooth(ttr, _CETR_SIGMA)
+
+	deriv := make([]float64, len(ttr))
+	for k := range ttr {
+		n, sum := 0, 0.0
+		for j := k; j < k+_CETR_ALPHA && j < len(ttr); j++ {
+			sum += ttr[j]
+			n++
+		}
+		deriv[k] = math.Abs(sum/float64(n) - ttr[k])
+	}
+
+	points := make([][2]float64, len(idx))
+	for k := range points {
+		points[k] = [2]float64{ttr[k], deriv[k]}
+	}
+
+	assign, centroids := kmeans(points, _CETR_CLUSTERS)
+	boilerplate := 0
+	for c := range centroids {
+		if math.Hypot(centroids[c][0], centroids[c][1]) < math.Hypot(centroids[boilerplate][0], centroids[boilerplate][1]) {
+			boilerplate = c
+		}
+	}
+
+	for k, i := range idx {
+		if s.Decided(i) {
+			continue
+		}
+		if assign[k] == boilerplate {
+			s.Drop(i, "text to tag ratio %.2f in the boilerplate cluster", ttr[k])
+		} else {
+			s.Keep(i, "text to tag ratio %.2f in a content cluster", ttr[k])
+		}
+	}
+}
+
+// Returns the approximate number of html tags of the text of e: one for each element directly containing its text and one for each link
+func tagCount(e *element) int {
+	parents := map[*html.Node]bool{}
+	for _, source := range e.allSources() {
+		if source.Parent != nil {
+			parents[source.Parent] = true
+		}
+	}
+	n := 1 + len(parents)
+	stack := []*element{e}
+	for len(stack) > 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		n += len(e.hrefs)
+		for _, child := range e.childs {
+			if child != nil {
+				stack = append(stack, child)
+			}
+		}
+	}
+	return n
+}
+
+// Returns v convolved with a gaussian kernel with standard deviation sigma
+func gaussianSmooth(v []float64, sigma float64) []float64 {
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	for i := range kernel {
+		x := float64(i - radius)
+		kernel[i] = math.Exp(-x * x / (2 * sigma * sigma))
+	}
+	r := make([]float64, len(v))
+	for i := range v {
+		sum, weight := 0.0, 0.0
+		for j := range kernel {
+			if k := i + j - radius; k >= 0 && k < len(v) {
+				sum += kernel[j] * v[k]
+				weight += kernel[j]
+			}
+		}
+		r[i] = sum / weight
+	}
+	return r
+}
+
+// Partitions points into k clusters, returns the cluster of each point and the centroids of the clusters.
+// The centroids start at the points with the smallest, median and largest first coordinate (evenly spaced for other values of k).
+func kmeans(points [][2]float64, k int) (assign []int, centroids [][2]float64) {
+	sorted := make([]int, len(points))
+	for i := range sorted {
+		sorted[i] = i
+	}
+	sort.SliceStable(sorted, func(a, b int) bool { return points[sorted[a]][0] < points[sorted[b]][0] })
+	centroids = make([][2]float64, k)
+	for c := range centroids {
+		centroids[c] = points[sorted[c*(len(points)-1)/(k-1)]]
+	}
+
+	assign = make([]int, len(points))
+	for it := 0; it < 100; it++ {
+		changed := it == 0
+		for i, p := range points {
+			best := 0
+			for c := range centroids {
+				if dist2(p, centroids[c]) < dist2(p, centroids[best]) {
+					best = c
+				}
+			}
+			if assign[i] != best {
+				assign[i] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+		sums := make([][2]float64, k)
+		counts := make([]int, k)
+		for i, p := range points {
+			sums[assign[i]][0] += p[0]
+			sums[assign[i]][1] += p[1]
+			counts[assign[i]]++
+		}
+		for c := range centroids {
+			if counts[c] > 0 {
+				centroids[c] = [2]float64{sums[c][0] / float64(counts[c]), sums[c][1] / float64(counts[c])}
+			}
+		}
+	}
+	return assign, centroids
+}
+
+func dist2(a, b [2]float64) float64 {
+	return (a[0]-b[0])*(a[0]-b[0]) + (a[1]-b[1])*(a[1]-b[1])
+}
@@ -0,0 +1,88 @@
+package sandblast
+
+import (
+	"golang.org/x/net/html"
+	"math"
+	"strings"
+)
+
+const (
+	_CONFIDENCE_LENGTH = 1000 // amount of ok text (in characters) that gives a confidence of about 0.63, before the other factors
+	_CONFIDENCE_GAP    = 3    // ok text blocks separated by more than this number of other blocks are in different clusters
+)
+
+// Returns the confidence that the blocks of a flattened tree come from an article, from the amount of ok text,
+// its fraction of the text of the document and how much of it is concentrated in a single cluster of blocks
+func (s *pageSignals) confidence() float64 {
+	if s == nil || s.okChars == 0 {
+		return 0
+	}
+	amount := 1 - math.Exp(-s.okChars/_CONFIDENCE_LENGTH)
+	ratio := math.Min(1, 2*s.okChars/s.totalChars)
+	concentration := s.bestCluster / s.okChars
+	return amount * (0.5 + 0.5*ratio) * (0.4 + 0.6*concentration)
+}
+
+const (
+	_READABLE_MIN_LENGTH = 140
+	_READABLE_MIN_SCORE  = 20
+)
+
+// Returns true if the document probably contains an article. It is a quick check that doesn't run the extraction:
+// paragraphs (p and pre elements) longer than 140 characters that aren't in elements whose class or id mark them as boilerplate
+// add to a score that grows with their length.
+func IsProbablyReadable(node *html.Node) bool {
+	if node == nil {
+		return false
+	}
+	score := 0.0
+	walkNodes(node, func(child *html.Node) bool {
+		if score > _READABLE_MIN_SCORE || child.Type != html.ElementNode {
+			return false
+		}
+		if getNodeKind(child) == _K_SUPPRESSED || isUnlikelyCandidate(child) {
+			return false
+		}
+		switch strings.ToLower(child.Data) {
+		case "p", "pre":
+			if length := textLength(nodeText(child, nil)); length >= _READABLE_MIN_LENGTH {
+				score += math.Sqrt(float64(length - _READABLE_MIN_LENGTH))
+			}
+			return false
+		}
+		return true
+	})
+	return score > _READABLE_MIN_SCORE
+}
+
+// Returns true if the class or id of node mark it as boilerplate and not as content, or if it is hidden
+func isUnlikelyCandidate(node *html.Node) bool {
+	for _, attr := range node.Attr {
+		if strings.ToLower(attr.Key) == "hidden" {
+			return true
+		}
+	}
+	if strings.Contains(strings.ReplaceAll(strings.ToLower(getAttribute(node, "style")), " ", ""), "display:none") {
+		return true
+	}
+	hints := strings.ToLower(getAttribute(node, "class") + " " + getAttribute(node, "id"))
+	if strings.TrimSpace(hints) == "" {
+		return false
+	}
+	unlikely := false
+	for _, hint := range readabilityNegative {
+		if strings.Contains(hints, hint) {
+			unlikely = true
+			break
+		}
+	}
+	if !unlikely {
+		return false
+	}
+	for _, hint := range readabilityPositive {
+		if strings.Contains(hints, hint) {
+			return false
+		}
+	}
+	return true
+}
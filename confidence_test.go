@@ -0,0 +1,40 @@
+package sandblast
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+const confidenceParagraph = "The city council met on Tuesday evening to discuss the plan for the new bridge, which has been delayed for more than two years because of a long dispute between the city and the company that won the contract to build it."
+
+func TestConfidence(t *testing.T) {
+	tf := func(name, doc string, min, max float64, readable bool) {
+		node, err := html.Parse(strings.NewReader(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out := IsProbablyReadable(node); out != readable {
+			t.Errorf("Error checking if %s is readable\n\tgot <%v>\n\texpected <%v>\n", name, out, readable)
+		}
+		r, _ := ExtractResult(node, 0)
+		if r.Confidence < min || r.Confidence > max {
+			t.Errorf("Error computing confidence of %s\n\tgot <%g>\n\texpected between <%g> and <%g>\n", name, r.Confidence, min, max)
+		}
+	}
+
+	article := "<html><body><h1>The new bridge</h1>" + strings.Repeat("<p>"+confidenceParagraph+"</p>", 8) + "</body></html>"
+	tf("article", article, 0.6, 1, true)
+
+	listing := "<html><body><ul>"
+	for i := 0; i < 20; i++ {
+		listing += "<li><a href='/story'>A story about the new bridge</a></li>"
+	}
+	listing += "</ul><p>" + confidenceParagraph + "</p>" + strings.Repeat("<div><a href='/x'>Another story</a> about something</div>", 10) + "<p>" + confidenceParagraph + "</p></body></html>"
+	tf("listing", listing, 0, 0.3, false)
+
+	hidden := "<html><body><div class='comments'>" + strings.Repeat("<p>"+confidenceParagraph+"</p>", 8) + "</div></body></html>"
+	tf("comments", hidden, 0.6, 1, false)
+
+	tf("empty page", "<html><body><p>Login</p></body></html>", 0, 0, false)
+}
@@ -14,6 +14,8 @@ const (
 	_K_KOTCONTAINER
 	_K_FORMATTING
 	_K_INLINE
+	_K_FIGURE
+	_K_MEDIA
 )
 
 var elements = map[string]nodeKind{
@@ -23,13 +25,13 @@ var elements = map[string]nodeKind{
 	"script": _K_SUPPRESSED, "noscript": _K_SUPPRESSED, "style": _K_SUPPRESSED,
 	"input": _K_SUPPRESSED, "label": _K_SUPPRESSED, "textarea": _K_SUPPRESSED, "button": _K_SUPPRESSED,
 	"isindex": _K_SUPPRESSED,
-	"object":  _K_SUPPRESSED, "applet": _K_SUPPRESSED, "img": _K_SUPPRESSED, "map": _K_SUPPRESSED,
+	"applet":  _K_SUPPRESSED, "img": _K_SUPPRESSED, "map": _K_SUPPRESSED,
 	"address":  _K_SUPPRESSED,
 	"basefont": _K_SUPPRESSED,
-	"colgroup": _K_SUPPRESSED, "col": _K_SUPPRESSED, "caption": _K_SUPPRESSED,
+	"colgroup": _K_SUPPRESSED, "col": _K_SUPPRESSED,
 	"br": _K_SUPPRESSED, "hr": _K_SUPPRESSED,
 	"canvas": _K_SUPPRESSED,
-	"audio":  _K_SUPPRESSED, "video": _K_SUPPRESSED, "source": _K_SUPPRESSED, "track": _K_SUPPRESSED, "embed": _K_SUPPRESSED,
+	"source": _K_SUPPRESSED, "track": _K_SUPPRESSED,
 	"datalist": _K_SUPPRESSED, "keygen": _K_SUPPRESSED, "output": _K_SUPPRESSED,
 	"command": _K_SUPPRESSED, "progress": _K_SUPPRESSED,
 	"ruby": _K_SUPPRESSED, "rt": _K_SUPPRESSED, "rp": _K_SUPPRESSED,
@@ -39,10 +41,8 @@ var elements = map[string]nodeKind{
 	"tbody": _K_TODESTRUCTURE, "thread": _K_TODESTRUCTURE, "tfoot": _K_TODESTRUCTURE, "tr": _K_TODESTRUCTURE, "th": _K_TODESTRUCTURE,
 	"form": _K_TODESTRUCTURE, "fieldset": _K_TODESTRUCTURE,
 	"optgroup": _K_TODESTRUCTURE,
-	"iframe":   _K_TODESTRUCTURE,
 	"legend":   _K_TODESTRUCTURE, "bdo": _K_TODESTRUCTURE,
 	"abbr": _K_TODESTRUCTURE, "acronym": _K_TODESTRUCTURE,
-	"figure": _K_TODESTRUCTURE, "figcaption": _K_TODESTRUCTURE,
 
 	/* Container */
 	"div": _K_CONTAINER, "span": _K_CONTAINER,
@@ -52,13 +52,20 @@ var elements = map[string]nodeKind{
 	"menu": _K_CONTAINER,
 	"ul":   _K_CONTAINER, "ol": _K_CONTAINER, "li": _K_CONTAINER,
 	"blockquote": _K_CONTAINER, "p": _K_CONTAINER, "cite": _K_CONTAINER, "pre": _K_CONTAINER,
-	"h4": _K_CONTAINER, "h5": _K_CONTAINER, "h6": _K_CONTAINER,
 	"header": _K_CONTAINER, "hgroup": _K_CONTAINER, "main": _K_CONTAINER, "article": _K_CONTAINER, "aside": _K_CONTAINER, "footer": _K_CONTAINER, "details": _K_CONTAINER, "summary": _K_CONTAINER,
 	"nav": _K_CONTAINER, "section": _K_CONTAINER,
 	"dialog": _K_CONTAINER,
 
 	/* Keep Original Tag Container */
 	"h1": _K_KOTCONTAINER, "h2": _K_KOTCONTAINER, "h3": _K_KOTCONTAINER,
+	"h4": _K_KOTCONTAINER, "h5": _K_KOTCONTAINER, "h6": _K_KOTCONTAINER,
+	"caption": _K_KOTCONTAINER, "figcaption": _K_KOTCONTAINER,
+
+	/* Figure (destructured if it doesn't contain an image) */
+	"figure": _K_FIGURE,
+
+	/* Media (suppressed if the source can't be determined, iframes are destructured instead) */
+	"video": _K_MEDIA, "audio": _K_MEDIA, "iframe": _K_MEDIA, "embed": _K_MEDIA, "object": _K_MEDIA,
 
 	/* Formatting */
 	"tt":    _K_FORMATTING,
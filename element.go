@@ -17,6 +17,11 @@ type element struct {
 	originalTag string
 	linkPart    float32
 	hrefs       []string
+	id          string
+	figure      *Figure
+	media       *Media
+	span        int          // for ~caption elements, number of following elements that belong to the captioned table
+	sources     []*html.Node // text nodes (or figure and media elements) the element was built from
 }
 
 const (
@@ -24,20 +29,41 @@ const (
 	_LINK_END   = "\x13"
 )
 
+// Returns a deep copy of el, the tree is visited without recursion
 func (el *element) Clone() *element {
+	r := el.cloneNode()
+	stack := []*element{r}
+	for len(stack) > 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for i := range e.childs {
+			if e.childs[i] != nil {
+				e.childs[i] = e.childs[i].cloneNode()
+				stack = append(stack, e.childs[i])
+			}
+		}
+	}
+	return r
+}
+
+// Returns a copy of el that shares its children
+func (el *element) cloneNode() *element {
 	r := &element{}
 	r.tag = el.tag
 	r.content = el.content
 	r.collapse = el.collapse
 	r.originalTag = el.originalTag
 	r.linkPart = el.linkPart
+	r.id = el.id
+	r.figure = el.figure
+	r.media = el.media
+	r.span = el.span
+	r.sources = el.sources
 	r.hrefs = make([]string, len(el.hrefs))
 	copy(r.hrefs, el.hrefs)
 	if el.childs != nil {
 		r.childs = make([]*element, len(el.childs))
-		for i := range r.childs {
-			r.childs[i] = el.childs[i].Clone()
-		}
+		copy(r.childs, el.childs)
 	} else {
 		r.childs = nil
 	}
@@ -45,11 +71,11 @@ func (el *element) Clone() *element {
 }
 
 func newContentElement(tag, content string) *element {
-	return &element{tag, nil, content, false, "", 0.0, nil}
+	return &element{tag: tag, content: content}
 }
 
 func newChildElement(tag string, childs []*element) *element {
-	return &element{tag, childs, "", false, "", 0.0, nil}
+	return &element{tag: tag, childs: childs}
 }
 
 // Returns a representation of the element suitable for debugging the library
@@ -59,39 +85,71 @@ func (e *element) DebugString() string {
 	return string(out.Bytes())
 }
 
+// Writes e and its descendants, the tree is visited without recursion
 func (e *element) debugStringEx(out *bytes.Buffer, depth int) {
+	type frame struct {
+		e     *element
+		i     int // next child to write
+		depth int
+		sep   bool
+	}
+	e.debugLine(out, depth)
+	stack := []frame{{e: e, depth: depth}}
+	for len(stack) > 0 {
+		f := &stack[len(stack)-1]
+		if f.i >= len(f.e.childs) {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		child := f.e.childs[f.i]
+		f.i++
+		if child != nil {
+			f.sep = false
+			child.debugLine(out, f.depth+1)
+			stack = append(stack, frame{e: child, depth: f.depth + 1})
+		} else {
+			if !f.sep {
+				out.Write([]byte{'\n'})
+				f.sep = true
+			}
+
+			fmt.Fprintf(out, "%s%v\n", makeIndent(f.depth+1), child)
+		}
+	}
+}
+
+// Writes the line describing e (without its descendants)
+func (e *element) debugLine(out *bytes.Buffer, depth int) {
 	out.Write([]byte(makeIndent(depth)))
 
 	fmt.Fprintf(out, "<%s", e.tag)
 	if e.originalTag != "" {
 		fmt.Fprintf(out, ":%s", e.originalTag)
 	}
+	if e.id != "" {
+		fmt.Fprintf(out, "#%s", e.id)
+	}
 	if e.linkPart > 0.001 {
 		fmt.Fprintf(out, ":%g", e.linkPart)
 	}
 	if len(e.hrefs) > 0 {
 		fmt.Fprintf(out, ":%s", strings.Join(e.hrefs, ","))
 	}
+	if e.figure != nil {
+		fmt.Fprintf(out, ":img=%s", e.figure.Src)
+	}
+	if e.media != nil {
+		fmt.Fprintf(out, ":%s=%s", e.media.Type, e.media.URL)
+	}
+	if e.span > 0 {
+		fmt.Fprintf(out, ":span=%d", e.span)
+	}
 	out.Write([]byte{'>'})
 	if e.childs == nil {
 		var lctxt linkContext
 		fmt.Fprintf(out, "[%s(%d)]\n", lctxt.convertLinks(e.content, true), len(e.content))
 	} else {
 		fmt.Fprintf(out, "[%d]\n", len(e.childs))
-		sep := false
-		for _, child := range e.childs {
-			if child != nil {
-				child.debugStringEx(out, depth+1)
-				sep = false
-			} else {
-				if !sep {
-					out.Write([]byte{'\n'})
-					sep = true
-				}
-
-				fmt.Fprintf(out, "%s%v\n", makeIndent(depth+1), child)
-			}
-		}
 	}
 }
 
@@ -117,6 +175,10 @@ func (e *element) String(flags Flags) string {
 }
 
 func (e *element) stringEx(out *bytes.Buffer, flags Flags, lctxt *linkContext) {
+	if e.childs == nil && len(e.hrefs) == 0 && strings.TrimSpace(e.content) == "" && e.placeholder(flags) == "" {
+		// figures and media without caption or placeholder
+		return
+	}
 	if e.childs == nil {
 		if len(e.hrefs) > 0 {
 			ctnt := lctxt.convertLinks(e.content, flags&KeepLinks != 0)
@@ -125,6 +187,12 @@ func (e *element) stringEx(out *bytes.Buffer, flags Flags, lctxt *linkContext) {
 		} else {
 			io.WriteString(out, strings.TrimSpace(e.content))
 		}
+		if p := e.placeholder(flags); p != "" {
+			if strings.TrimSpace(e.content) != "" {
+				out.Write([]byte{' '})
+			}
+			io.WriteString(out, p)
+		}
 	} else {
 		out.Write([]byte{'\n'})
 		sep := true
@@ -143,6 +211,17 @@ func (e *element) stringEx(out *bytes.Buffer, flags Flags, lctxt *linkContext) {
 	out.Write([]byte{'\n'})
 }
 
+// Returns the placeholder written in the text for figures and media
+func (e *element) placeholder(flags Flags) string {
+	switch {
+	case e.figure != nil && e.figure.Src != "" && flags&KeepImages != 0:
+		return fmt.Sprintf("[img: %s]", e.figure.Src)
+	case e.media != nil && flags&MarkMedia != 0:
+		return fmt.Sprintf("[%s: %s]", e.media.Type, e.media.URL)
+	}
+	return ""
+}
+
 type linkContext struct {
 	cnt   int
 	hrefs []string
@@ -175,15 +254,30 @@ func (e *element) isHeader() bool {
 	if e.tag != "~textdiv" && e.tag != "~text" {
 		return false
 	}
-	return e.originalTag == "h"
+	return e.headingLevel() > 0
+}
+
+// Returns the level of the heading this element was built from (1 to 6) or 0 if it isn't a heading
+func (e *element) headingLevel() int {
+	if len(e.originalTag) != 2 || e.originalTag[0] != 'h' || e.originalTag[1] < '1' || e.originalTag[1] > '6' {
+		return 0
+	}
+	return int(e.originalTag[1] - '0')
 }
 
-func (e *element) isLinkList() bool {
+func (e *element) isCaption() bool {
+	if e.tag != "~textdiv" && e.tag != "~text" {
+		return false
+	}
+	return e.originalTag == "caption" || e.originalTag == "figcaption"
+}
+
+func (e *element) isLinkList(t *Thresholds) bool {
 	if e.childs == nil {
 		return false
 	}
 
-	if len(e.childs) < 5 {
+	if len(e.childs) < t.LinkListSize {
 		return false
 	}
 
@@ -196,31 +290,27 @@ func (e *element) isLinkList() bool {
 		if child.tag != "~text" && child.tag != "~textdiv" {
 			return false
 		}
-		if child.linkPart > 0.70 {
+		if float64(child.linkPart) > t.LinkListItem {
 			nlinks++
 		}
 	}
 	if nlinks < 2 {
 		return false
 	}
-	return (nlinks >= len(e.childs)-2) || (nlinks > int(float32(len(e.childs))*0.75))
+	return (nlinks >= len(e.childs)-2) || (nlinks > int(float64(len(e.childs))*t.LinkListRatio))
 }
 
-func (e *element) isLinkBlob() bool {
+func (e *element) isLinkBlob(t *Thresholds) bool {
 	if e.tag != "~text" && e.tag != "~textdiv" {
 		return false
 	}
-	return e.linkPart > 0.7
-}
-
-func (e *element) okText() bool {
-	return e != nil && e.tag == "~textblock" && len(e.content) > 50
+	return float64(e.linkPart) > t.LinkBlob
 }
 
 /* Fuses a text element to the last text element in childs.
 If this is not possible (for example because childs doesn't end with a text element) returns false
 */
-func pushTextEx(childs []*element, ts string, hrefs []string, tsLinkPart float32) bool {
+func pushTextEx(childs []*element, ts string, hrefs []string, tsLinkPart float32, sources []*html.Node) bool {
 	if childs == nil || len(childs) == 0 {
 		return false
 	}
@@ -228,10 +318,11 @@ func pushTextEx(childs []*element, ts string, hrefs []string, tsLinkPart float32
 	if last.tag != "~text" {
 		return false
 	}
-	newLinkPart := float32(len(ts))*tsLinkPart + float32(len(last.content))*last.linkPart
+	newLinkPart := float32(textLength(ts))*tsLinkPart + float32(textLength(last.content))*last.linkPart
 	last.content += " " + ts
-	last.linkPart = newLinkPart / float32(len(last.content))
+	last.linkPart = newLinkPart / float32(textLength(last.content))
 	last.hrefs = append(last.hrefs, hrefs...)
+	last.sources = append(last.sources, sources...)
 	return true
 }
 
@@ -247,9 +338,12 @@ func pushText(childs []*element, node *html.Node) []*element {
 		return childs
 	}
 
-	added := pushTextEx(childs, string(ts), nil, 0.0)
+	sources := []*html.Node{node}
+	added := pushTextEx(childs, string(ts), nil, 0.0, sources)
 	if !added {
-		childs = append(childs, newContentElement("~text", string(ts)))
+		e := newContentElement("~text", string(ts))
+		e.sources = sources
+		childs = append(childs, e)
 	}
 	return childs
 }
@@ -258,7 +352,7 @@ func pushElement(childs []*element, child *element) []*element {
 	if !child.collapse {
 		added := false
 		if child.tag == "~text" {
-			added = pushTextEx(childs, child.content, child.hrefs, child.linkPart)
+			added = pushTextEx(childs, child.content, child.hrefs, child.linkPart, child.sources)
 		}
 		if !added {
 			childs = append(childs, child)
@@ -269,7 +363,7 @@ func pushElement(childs []*element, child *element) []*element {
 		for _, cc := range child.childs {
 			added := false
 			if cc.tag == "~text" {
-				added = pushTextEx(childs, cc.content, cc.hrefs, cc.linkPart)
+				added = pushTextEx(childs, cc.content, cc.hrefs, cc.linkPart, cc.sources)
 			}
 			if !added {
 				childs = append(childs, cc)
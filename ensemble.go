@@ -0,0 +1,162 @@
+package sandblast
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"math"
+	"strings"
+)
+
+// An extractor that combines the votes of several independent block classifiers.
+// Every classifier votes between -1 (boilerplate) and 1 (content), a block is kept if the weighted mean of the votes is greater than Threshold.
+// A zero weight disables a classifier.
+type Ensemble struct {
+	Rules           float64 // the rules of DefaultExtractor
+	LinkDensity     float64 // fraction of the text of the block inside links, only votes against blocks with more than a quarter of their text in links
+	StopwordDensity float64 // fraction of the words of the block that are stopwords, needs the language of the document
+	ClassHints      float64 // class and id attributes of the elements containing the block, and their tags (nav, footer, article...)
+	Position        float64 // position of the block in the document, blocks at its beginning and end are often boilerplate
+
+	Threshold float64
+}
+
+// Ensemble with the default weights, copy it to change them
+var EnsembleExtractor Extractor = Ensemble{Rules: 1, LinkDensity: 1, StopwordDensity: 1, ClassHints: 0.5, Position: 0.5}
+
+func (en Ensemble) Name() string { return "ensemble" }
+
+func (en Ensemble) Classify(s *BlockSet) {
+	blocks := s.parent.childs
+
+	var rules []*element
+	if en.Rules != 0 {
+		// the rules run on a copy of the blocks
+		parent := &element{tag: s.parent.tag, childs: make([]*element, len(blocks))}
+		copy(parent.childs, blocks)
+		cleanRules(&BlockSet{parent: parent, blocks: s.blocks, actions: s.actions, flags: s.flags, profile: s.profile})
+		rules = parent.childs
+	}
+
+	n := 0
+	for _, block := range blocks {
+		if block != nil {
+			n++
+		}
+	}
+
+	keep := make([]bool, len(blocks))
+	reasons := make([]string, len(blocks))
+	pos := 0
+	for i, block := range blocks {
+		if block == nil {
+			continue
+		}
+		var votes [5]float64
+		if rules != nil {
+			votes[0] = -1
+			if rules[i] != nil {
+				votes[0] = 1
+			}
+		}
+		votes[1] = clamp(1-4*float64(block.linkPart), -1, 0)
+		votes[2] = stopwordVote(block, s.profile)
+		votes[3] = classHintsVote(block)
+		if n > 1 {
+			p := float64(pos) / float64(n-1)
+			votes[4] = clamp(8*math.Min(p, 1-p)-1, -1, 1)
+		}
+		pos++
+
+		weights := [5]float64{en.Rules, en.LinkDensity, en.StopwordDensity, en.ClassHints, en.Position}
+		sum, total := 0.0, 0.0
+		for k := range votes {
+			sum += weights[k] * votes[k]
+			total += math.Abs(weights[k])
+		}
+		score := 0.0
+		if total > 0 {
+			score = sum / total
+		}
+		keep[i] = score > en.Threshold
+		reasons[i] = fmt.Sprintf("ensemble score %.2f (rules %+.0f, links %+.2f, stopwords %+.2f, hints %+.0f, position %+.2f)", score, votes[0], votes[1], votes[2], votes[3], votes[4])
+	}
+
+	// headings follow the block after them
+	for i := len(blocks) - 1; i >= 0; i-- {
+		if blocks[i] == nil || blocks[i].tag != "~header" {
+			continue
+		}
+		for j := i + 1; j < len(blocks); j++ {
+			if blocks[j] != nil {
+				keep[i] = keep[j]
+				break
+			}
+		}
+	}
+
+	for i, block := range blocks {
+		if s.Decided(i) || block.span > 0 {
+			continue
+		}
+		if keep[i] {
+			s.Keep(i, "%s", reasons[i])
+		} else {
+			s.Drop(i, "%s", reasons[i])
+		}
+	}
+}
+
+// Votes for blocks with many stopwords, blocks with few words get weaker votes
+func stopwordVote(block *element, profile *languageProfile) float64 {
+	if profile == nil || profile.stopwords == nil {
+		return 0
+	}
+	words := splitWords(blockText(block))
+	if len(words) == 0 {
+		return -1
+	}
+	n := 0
+	for _, w := range words {
+		if profile.isStopword(w) {
+			n++
+		}
+	}
+	density := float64(n) / float64(len(words))
+	return clamp((density-0.2)/0.15, -1, 1) * math.Min(1, float64(wordCount(blockText(block)))/10)
+}
+
+// Tags of elements that contain content or boilerplate
+var (
+	contentTags     = map[string]bool{"article": true, "main": true}
+	boilerplateTags = map[string]bool{"nav": true, "aside": true, "footer": true, "header": true, "menu": true}
+)
+
+// Votes according to the closest element containing the block that has a tag or a class or id attribute hinting at content or boilerplate
+func classHintsVote(block *element) float64 {
+	sources := block.allSources()
+	if len(sources) == 0 {
+		return 0
+	}
+	for node := sources[0].Parent; node != nil; node = node.Parent {
+		if node.Type != html.ElementNode {
+			continue
+		}
+		tag := strings.ToLower(node.Data)
+		switch {
+		case boilerplateTags[tag]:
+			return -1
+		case contentTags[tag]:
+			return 1
+		}
+		if score := readabilityBaseScore(node); score >= 20 {
+			return 1
+		} else if score <= -20 {
+			return -1
+		}
+	}
+	return 0
+}
+
+func clamp(x, min, max float64) float64 {
+	return math.Max(min, math.Min(max, x))
+}
@@ -0,0 +1,40 @@
+package sandblast
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestEnsemble(t *testing.T) {
+	tf := func(ex Ensemble, present, absent []string) {
+		node, err := html.Parse(strings.NewReader(extractorTestDocument))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, _ := ExtractWithOptions(node, &Options{Extractor: ex})
+		for _, s := range present {
+			if !strings.Contains(r.Text, s) {
+				t.Errorf("Error extracting with %+v: missing <%s> in <%s>", ex, s, r.Text)
+			}
+		}
+		for _, s := range absent {
+			if strings.Contains(r.Text, s) {
+				t.Errorf("Error extracting with %+v: boilerplate <%s> in <%s>", ex, s, r.Text)
+			}
+		}
+	}
+	article := []string{"The new bridge", "The city council met", "According to the mayor", "The opposition, however"}
+	tf(EnsembleExtractor.(Ensemble), article, []string{"Other story", "Subscribe", "Copyright"})
+	// the rules alone keep the footer, the class hints alone drop it
+	tf(Ensemble{Rules: 1}, append(article, "Copyright"), []string{"Other story", "Subscribe"})
+	tf(Ensemble{ClassHints: 1}, article, []string{"Subscribe", "Copyright"})
+	// a high threshold drops everything
+	tf(Ensemble{Rules: 1, Threshold: 1}, nil, article)
+
+	en := EnsembleExtractor.(Ensemble)
+	en.Rules = 0
+	if EnsembleExtractor.(Ensemble).Rules != 1 {
+		t.Errorf("Default ensemble changed by a copy")
+	}
+}
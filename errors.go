@@ -0,0 +1,32 @@
+package sandblast
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNoRoot        = errors.New("Could not find root")                  // The document doesn't contain any element
+	ErrNotHTML       = errors.New("Not an HTML document")                 // The document doesn't have an html or body element
+	ErrEmptyContent  = errors.New("No text extracted")                    // The extraction didn't produce any text, the result is still returned
+	ErrDepthExceeded = errors.New("Document nested deeper than MaxDepth") // Parts of the document were dropped because of Options.MaxDepth, the result is still returned
+)
+
+// Returned, together with the result, when parts of the document were dropped because they were nested deeper than Options.MaxDepth.
+// Matches ErrDepthExceeded.
+type DepthError struct {
+	MaxDepth int
+}
+
+func (err *DepthError) Error() string {
+	return fmt.Sprintf("Document nested deeper than %d elements", err.MaxDepth)
+}
+
+func (err *DepthError) Is(target error) bool {
+	return target == ErrDepthExceeded
+}
+
+// Returns true for errors that are returned together with a usable result (ErrEmptyContent and ErrDepthExceeded)
+func IsPartialError(err error) bool {
+	return errors.Is(err, ErrEmptyContent) || errors.Is(err, ErrDepthExceeded)
+}
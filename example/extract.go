@@ -4,13 +4,17 @@ import (
 	"os"
 	"fmt"
 	"bytes"
+	"encoding/json"
 	"github.com/aarzilli/sandblast"
 	"golang.org/x/net/html"
 	"log"
 )
 
 func usage() {
-	fmt.Fprintf(os.Stderr, "Usage: extract <url> [debug]\n")
+	fmt.Fprintf(os.Stderr, "Usage: extract <url> [debug|html|json]\n")
+	fmt.Fprintf(os.Stderr, "\tdebug: prints the intermediate trees\n")
+	fmt.Fprintf(os.Stderr, "\thtml: prints the page with kept and dropped blocks highlighted\n")
+	fmt.Fprintf(os.Stderr, "\tjson: prints the intermediate trees as JSON\n")
 	os.Exit(1)
 }
 
@@ -21,10 +25,17 @@ func main() {
 	
 	url := os.Args[1]
 	isDebug := false
+	isHtml := false
+	isJson := false
 	if len(os.Args) >= 3 {
-		if os.Args[2] == "debug" {
+		switch os.Args[2] {
+		case "debug":
 			isDebug = true
-		} else {
+		case "html":
+			isHtml = true
+		case "json":
+			isJson = true
+		default:
 			usage()
 		}
 	}
@@ -38,9 +49,34 @@ func main() {
 	if err != nil {
 		log.Fatal("Parsing error: ", err)
 	}
+	if isHtml {
+		r, err := sandblast.ExtractResult(node, sandblast.KeepLinks|sandblast.Explain)
+		if err != nil {
+			if !sandblast.IsPartialError(err) {
+				log.Fatal("Extraction error: ", err)
+			}
+			log.Print("Warning: ", err)
+		}
+		if err := r.WriteAnnotatedHTML(os.Stdout); err != nil {
+			log.Fatal("Rendering error: ", err)
+		}
+		return
+	}
+	
 	title, text, simplified, flattened, cleaned, err := sandblast.ExtractEx(node, sandblast.KeepLinks)
 	if err != nil {
-		log.Fatal("Extraction error: ", err)
+		if !sandblast.IsPartialError(err) {
+			log.Fatal("Extraction error: ", err)
+		}
+		log.Print("Warning: ", err)
+	}
+	
+	if isJson {
+		trees := map[string]interface{}{ "simplified": simplified, "flattened": flattened, "cleaned": cleaned }
+		if err := json.NewEncoder(os.Stdout).Encode(trees); err != nil {
+			log.Fatal("Encoding error: ", err)
+		}
+		return
 	}
 	
 	fmt.Printf("TITLE: %s\n", title)
@@ -0,0 +1,81 @@
+package sandblast
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"unicode"
+)
+
+const _DEFAULT_EXCERPT_LENGTH = 300
+
+// Returns a short excerpt of the document: its description, if it has one, otherwise its first paragraph of ok text.
+// The excerpt is trimmed to maxLength characters at a sentence boundary, if possible.
+func findExcerpt(roots []*html.Node, cleaned *element, profile *languageProfile, maxLength int) string {
+	if maxLength <= 0 {
+		maxLength = _DEFAULT_EXCERPT_LENGTH
+	}
+
+	for _, root := range roots {
+		if description := getMeta(root, "description", "og:description", "twitter:description"); description != "" {
+			return trimExcerpt(string(collapseWhitespace([]rune(description))), maxLength)
+		}
+	}
+
+	if cleaned == nil {
+		return ""
+	}
+	blocks := cleaned.childs
+	if blocks == nil {
+		blocks = []*element{cleaned}
+	}
+	var first *element
+	for _, block := range blocks {
+		if profile.okText(block) {
+			return trimExcerpt(blockText(block), maxLength)
+		}
+		if first == nil && block != nil && block.tag == "~textblock" {
+			first = block
+		}
+	}
+	if first != nil {
+		return trimExcerpt(blockText(first), maxLength)
+	}
+	return ""
+}
+
+// Trims text to at most maxLength characters, at the end of a sentence if possible or at the end of a word,
+// text cut in the middle of a sentence ends with "…", which counts as one character
+func trimExcerpt(text string, maxLength int) string {
+	text = strings.TrimSpace(text)
+	if len([]rune(text)) <= maxLength {
+		return text
+	}
+
+	r := ""
+	for _, sentence := range splitSentences(text) {
+		next := sentence
+		if r != "" {
+			next = r + " " + sentence
+		}
+		if len([]rune(next)) > maxLength {
+			break
+		}
+		r = next
+	}
+	if r != "" || maxLength < 1 {
+		return r
+	}
+
+	rtext := []rune(text)[:maxLength-1] // one character is left for the ellipsis
+	end := len(rtext)
+	for end > 0 && !unicode.IsSpace(rtext[end-1]) {
+		end--
+	}
+	if end == 0 {
+		// no spaces (for example CJK text), cut anywhere
+		end = len(rtext)
+	}
+	return strings.TrimRightFunc(string(rtext[:end]), func(ch rune) bool {
+		return unicode.IsSpace(ch) || unicode.IsPunct(ch)
+	}) + "…"
+}
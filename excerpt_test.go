@@ -0,0 +1,40 @@
+package sandblast
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestTrimExcerpt(t *testing.T) {
+	tf := func(in string, maxLength int, target string) {
+		out := trimExcerpt(in, maxLength)
+		if out != target {
+			t.Errorf("Error trimming excerpt <%s> to %d\n\tgot <%s>\n\texpected <%s>\n", in, maxLength, out, target)
+		}
+	}
+	tf("Short text.", 50, "Short text.")
+	tf("First sentence. Second sentence. Third sentence.", 35, "First sentence. Second sentence.")
+	tf("A single very long sentence without any end in sight", 20, "A single very long…")
+	tf("A single very long sentence without any end in sight", 19, "A single very…")
+	tf("これはとても長い文章です", 5, "これはと…")
+	tf("これはとても長い文章です", 1, "…")
+}
+
+func TestExcerpt(t *testing.T) {
+	tf := func(doc string, maxLength int, target string) {
+		node, err := html.Parse(strings.NewReader(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, _ := ExtractWithOptions(node, &Options{ExcerptLength: maxLength})
+		if r.Excerpt != target {
+			t.Errorf("Error finding excerpt of <%s>\n\tgot <%s>\n\texpected <%s>\n", doc, r.Excerpt, target)
+		}
+	}
+	const body = "<body><p>Short</p><p>This is the first paragraph of the article. It is long enough to be kept.</p></body>"
+	tf("<html><head><meta name='description' content='The description of the page.'></head>"+body+"</html>", 0, "The description of the page.")
+	tf("<html><head><meta property='og:description' content='The description of the page.'></head>"+body+"</html>", 0, "The description of the page.")
+	tf("<html>"+body+"</html>", 0, "This is the first paragraph of the article. It is long enough to be kept.")
+	tf("<html>"+body+"</html>", 50, "This is the first paragraph of the article.")
+}
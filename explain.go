@@ -0,0 +1,85 @@
+package sandblast
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"strings"
+)
+
+// Explains a decision taken during the extraction about a part of the document
+type Decision struct {
+	Stage   string // Stage of the extraction that took the decision: "simplify", "flatten" or "clean"
+	Kind    string // Kind of the block (see Block.Kind) or, for the simplify stage, tag of the element
+	Text    string // Text of the block or element, truncated
+	Dropped bool   // The block or element was removed from the document
+	Reason  string // Reason of the decision
+
+	el *element
+}
+
+const _EXPLAIN_TEXT_LENGTH = 80
+
+// Records decisions taken during the extraction, a nil *explainLog records nothing
+type explainLog struct {
+	decisions []*Decision
+}
+
+func (log *explainLog) add(stage, kind, text string, dropped bool, reason string, args ...interface{}) {
+	if log == nil {
+		return
+	}
+	rtext := []rune(strings.TrimSpace(string(collapseWhitespace([]rune(text)))))
+	if len(rtext) > _EXPLAIN_TEXT_LENGTH {
+		rtext = append(rtext[:_EXPLAIN_TEXT_LENGTH], '…')
+	}
+	log.decisions = append(log.decisions, &Decision{stage, kind, string(rtext), dropped, fmt.Sprintf(reason, args...), nil})
+}
+
+// Records the removal of node during simplify
+func (log *explainLog) addNode(node *html.Node, reason string, args ...interface{}) {
+	if log == nil {
+		return
+	}
+	log.add("simplify", strings.ToLower(node.Data), allText(node, 4*_EXPLAIN_TEXT_LENGTH), true, reason, args...)
+}
+
+// Records a decision about a block
+func (log *explainLog) addBlock(stage string, e *element, dropped bool, reason string, args ...interface{}) {
+	if log == nil {
+		return
+	}
+	log.add(stage, strings.TrimPrefix(e.tag, "~"), blockText(e), dropped, reason, args...)
+	log.decisions[len(log.decisions)-1].el = e
+}
+
+func (log *explainLog) list() []*Decision {
+	if log == nil {
+		return nil
+	}
+	return log.decisions
+}
+
+// Returns the text contained in node, including the text of suppressed elements, stops after collecting at least max bytes
+func allText(node *html.Node, max int) string {
+	out := []string{}
+	n := 0
+	for cur := node; cur != nil && n < max; {
+		if cur.Type == html.TextNode {
+			out = append(out, cur.Data)
+			n += len(cur.Data)
+		}
+		// visit the tree in order without recursion
+		if cur.FirstChild != nil {
+			cur = cur.FirstChild
+			continue
+		}
+		for cur != node && cur.NextSibling == nil {
+			cur = cur.Parent
+		}
+		if cur == node {
+			break
+		}
+		cur = cur.NextSibling
+	}
+	return strings.Join(out, " ")
+}
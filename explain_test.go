@@ -0,0 +1,42 @@
+package sandblast
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	const doc = `<html><head><script>var x = 1;</script></head><body>
+<p>This is the first paragraph of the article, long enough to be kept as text.</p>
+<p>Short one</p>
+<div><a href="/a">Some link to elsewhere</a> and text</div>
+<p>Some footer text</p>
+</body></html>`
+
+	node, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ExtractResult(node, Explain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := []string{}
+	for _, d := range r.Log {
+		out = append(out, fmt.Sprintf("%s %s %v <%s> %s", d.Stage, d.Kind, d.Dropped, d.Text, d.Reason))
+	}
+	tgt := []string{
+		"simplify head true <var x = 1;> suppressed tag",
+		"flatten linkblob false <Some link to elsewhere and text> link blob with linkPart 0.71",
+		"clean textblock false <This is the first paragraph of the article, long enough to be kept as text.> ok text",
+		"clean textblock true <Short one> textblock shorter than 15 characters",
+		"clean linkblob true <Some link to elsewhere and text> link blob with linkPart 0.71",
+		"clean textblock true <Some footer text> isolated block that isn't ok text",
+	}
+	if strings.Join(out, "\n") != strings.Join(tgt, "\n") {
+		t.Errorf("Wrong log\n\tgot:\n%s\n\texpected:\n%s\n", strings.Join(out, "\n"), strings.Join(tgt, "\n"))
+	}
+}
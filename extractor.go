@@ -0,0 +1,124 @@
+package sandblast
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"strings"
+)
+
+// An algorithm deciding which blocks of a document are part of its content.
+// Every extractor runs after the simplify and flatten stages, on the blocks of the flattened tree;
+// link lists and link blobs found by flatten are always dropped.
+// Extractors can be implemented outside of this package with the methods of BlockSet.
+type Extractor interface {
+	Name() string
+
+	// Drops the blocks that aren't content, the blocks that aren't dropped are kept
+	Classify(s *BlockSet)
+}
+
+// The blocks of a flattened tree during the clean stage, passed to Extractor.Classify
+type BlockSet struct {
+	parent  *element      // the blocks are the children of parent, dropped blocks are set to nil
+	blocks  []*element    // the blocks before the clean stage
+	actions []BlockAction // actions chosen by the hook for each block
+	flags   Flags
+	profile *languageProfile
+	reasons []string // reasons of the decisions, only recorded when log isn't nil
+	log     *explainLog
+	views   []*Block
+}
+
+// Returns the number of blocks
+func (s *BlockSet) Len() int {
+	return len(s.blocks)
+}
+
+// Returns a description of block i, nil if there is no block at position i.
+// Changing the returned block has no effect.
+func (s *BlockSet) Block(i int) *Block {
+	if s.views == nil {
+		s.views = blockViews(s.blocks)
+	}
+	return s.views[i]
+}
+
+// Returns the nodes of the document block i was built from, nil for trees without a document (see ExtractTree)
+func (s *BlockSet) Sources(i int) []*html.Node {
+	if s.blocks[i] == nil {
+		return nil
+	}
+	return s.blocks[i].allSources()
+}
+
+// Returns true if word (in lower case) is a stopword of the language of the document
+func (s *BlockSet) IsStopword(word string) bool {
+	return s.profile.isStopword(word)
+}
+
+// Returns true if block i was already dropped or must be kept because of the hook, Keep and Drop don't change it
+func (s *BlockSet) Decided(i int) bool {
+	return s.parent.childs[i] == nil || s.actions[i] == KeepBlock
+}
+
+// Drops block i, the reason is recorded in Result.Log when extracting with Explain
+func (s *BlockSet) Drop(i int, reason string, args ...interface{}) {
+	if s.actions[i] == KeepBlock {
+		return
+	}
+	s.parent.childs[i] = nil
+	if s.log != nil {
+		s.reasons[i] = fmt.Sprintf(reason, args...)
+	}
+}
+
+// Records the reason why block i was kept, blocks are kept by default
+func (s *BlockSet) Keep(i int, reason string, args ...interface{}) {
+	if s.log != nil && !s.Decided(i) {
+		s.reasons[i] = fmt.Sprintf(reason, args...)
+	}
+}
+
+type rulesExtractor struct{}
+
+func (rulesExtractor) Name() string { return "sandblast" }
+
+func (rulesExtractor) Classify(s *BlockSet) { cleanRules(s) }
+
+type justextExtractor struct{}
+
+func (justextExtractor) Name() string { return "justext" }
+
+func (justextExtractor) Classify(s *BlockSet) { classifyStopwords(s) }
+
+var (
+	// Length and link density rules
+	DefaultExtractor Extractor = rulesExtractor{}
+
+	// Stopword density classifier (jusText)
+	JusTextExtractor Extractor = justextExtractor{}
+
+	// Shallow text features (word counts and link density of a block and its neighbors), like boilerpipe
+	BoilerpipeExtractor Extractor = boilerpipeExtractor{}
+
+	// Clustering of the text to tag ratio of blocks, like CETR
+	CETRExtractor Extractor = cetrExtractor{}
+
+	// Scoring of the containers of paragraphs, like Readability
+	ReadabilityExtractor Extractor = readabilityExtractor{}
+)
+
+// Returns all the extractors
+func Extractors() []Extractor {
+	return []Extractor{DefaultExtractor, JusTextExtractor, BoilerpipeExtractor, CETRExtractor, ReadabilityExtractor, EnsembleExtractor}
+}
+
+// Returns the extractor with the specified name (case insensitive) or nil
+func ExtractorByName(name string) Extractor {
+	for _, ex := range Extractors() {
+		if strings.EqualFold(ex.Name(), name) {
+			return ex
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package sandblast
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+const extractorTestDocument = `<html lang="en"><body>
+<div id="header"><a href="/">Home</a> <a href="/news">News</a> <a href="/sport">Sport</a></div>
+<div class="sidebar"><p>Subscribe to our newsletter</p><ul><li><a href="/a">Other story one</a></li><li><a href="/b">Other story two</a></li><li><a href="/c">Other story three</a></li><li><a href="/d">Other story four</a></li><li><a href="/e">Other story five</a></li></ul></div>
+<div class="article">
+<h1>The new bridge</h1>
+<p>The city council met on Tuesday evening to discuss the plan for the new bridge, which has been delayed for more than two years because of a long dispute between the city and the company that won the contract to build it.</p>
+<p>According to the mayor, the work will start again in the spring and the bridge should be open to traffic by the end of next year, if the weather is not too bad and there are no other problems with the company.</p>
+<p>The opposition, however, said that the new schedule is not realistic, and that the city should look for another company to finish the work, since the costs have already doubled.</p>
+</div>
+<div id="footer"><p>Copyright 2024 The City Gazette</p></div>
+</body></html>`
+
+func TestExtractors(t *testing.T) {
+	for _, ex := range Extractors() {
+		if ExtractorByName(strings.ToUpper(ex.Name())) != ex {
+			t.Errorf("Extractor %s not found by name", ex.Name())
+		}
+		node, err := html.Parse(strings.NewReader(extractorTestDocument))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := ExtractWithOptions(node, &Options{Extractor: ex})
+		if err != nil {
+			t.Errorf("Error extracting with %s: %v", ex.Name(), err)
+			continue
+		}
+		for _, s := range []string{"The city council met", "According to the mayor", "The opposition, however"} {
+			if !strings.Contains(r.Text, s) {
+				t.Errorf("Error extracting with %s: missing <%s> in <%s>", ex.Name(), s, r.Text)
+			}
+		}
+		boilerplate := []string{"Other story", "Subscribe"}
+		if ex == JusTextExtractor || ex == ReadabilityExtractor {
+			// the other extractors keep short blocks next to the content
+			boilerplate = append(boilerplate, "Copyright")
+		}
+		for _, s := range boilerplate {
+			if strings.Contains(r.Text, s) {
+				t.Errorf("Error extracting with %s: boilerplate <%s> in <%s>", ex.Name(), s, r.Text)
+			}
+		}
+	}
+	if ExtractorByName("unknown") != nil {
+		t.Errorf("Unknown extractor found")
+	}
+}
+
+// An extractor using only the exported API: keeps the headings and the blocks that contain a comma
+type commaExtractor struct{}
+
+func (commaExtractor) Name() string { return "comma" }
+
+func (commaExtractor) Classify(s *BlockSet) {
+	for i := 0; i < s.Len(); i++ {
+		b := s.Block(i)
+		if b == nil || s.Decided(i) {
+			continue
+		}
+		if b.Kind != "header" && !strings.Contains(b.Text, ",") {
+			s.Drop(i, "no comma")
+		} else {
+			s.Keep(i, "comma or heading")
+		}
+	}
+}
+
+func TestCustomExtractor(t *testing.T) {
+	node, err := html.Parse(strings.NewReader(extractorTestDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook := BlockHookFunc(func(b *Block) BlockAction {
+		if strings.HasPrefix(b.Text, "Copyright") {
+			return KeepBlock
+		}
+		return DefaultBlock
+	})
+	r, err := ExtractWithOptions(node, &Options{Extractor: commaExtractor{}, Hook: hook, Flags: Explain})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const target = "The new bridge\nThe city council met"
+	if !strings.HasPrefix(strings.TrimSpace(r.Text), target) || !strings.Contains(r.Text, "Copyright") || strings.Contains(r.Text, "Subscribe") {
+		t.Errorf("Error extracting with a custom extractor: <%s>", r.Text)
+	}
+	reasons := map[string]bool{}
+	for _, d := range r.Log {
+		reasons[d.Reason] = true
+	}
+	if !reasons["no comma"] || !reasons["comma or heading"] || !reasons["kept by hook"] {
+		t.Errorf("Reasons of the custom extractor not logged: %v", reasons)
+	}
+}
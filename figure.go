@@ -0,0 +1,153 @@
+package sandblast
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+)
+
+// A figure of the extracted text
+type Figure struct {
+	Src     string // Source of the image
+	Alt     string // Alternative text of the image
+	Caption string // Caption of the figure, without the credit
+	Credit  string // Credit line of the figure (photographer, agency, copyright)
+}
+
+// Words that, found in the class or itemprop attributes of an element inside a figure, mark it as the credit line
+var creditHints = []string{"credit", "copyright", "attribution", "byline", "photographer"}
+
+// Converts a figure element to a ~figure element, returns nil if it doesn't contain an image
+func simplifyFigure(node *html.Node) *element {
+	img := findElement(node, "img")
+	if img == nil {
+		return nil
+	}
+
+	f := &Figure{Src: imageSource(img), Alt: strings.TrimSpace(getAttribute(img, "alt"))}
+
+	credit := findCredit(node)
+	if credit != nil {
+		f.Credit = nodeText(credit, nil)
+	}
+	if figcaption := findElement(node, "figcaption"); figcaption != nil {
+		f.Caption = nodeText(figcaption, credit)
+	}
+
+	content := f.Caption
+	if f.Credit != "" {
+		content = strings.TrimSpace(content + " (" + f.Credit + ")")
+	}
+
+	r := newContentElement("~figure", content)
+	r.figure = f
+	r.sources = []*html.Node{node}
+	return r
+}
+
+// Returns the source of an image, taking into account attributes used for lazy loading
+func imageSource(img *html.Node) string {
+	src := getAttribute(img, "src")
+	if src != "" && !strings.HasPrefix(src, "data:") {
+		return src
+	}
+	for _, name := range []string{"data-src", "data-lazy-src", "data-original", "srcset", "data-srcset"} {
+		v := strings.TrimSpace(getAttribute(img, name))
+		if v == "" {
+			continue
+		}
+		if strings.HasSuffix(name, "srcset") {
+			fields := strings.Fields(strings.Split(v, ",")[0])
+			if len(fields) == 0 {
+				continue
+			}
+			v = fields[0]
+		}
+		return v
+	}
+	return src
+}
+
+// Returns the first descendant of node whose class or itemprop attribute marks it as a credit line
+func findCredit(node *html.Node) *html.Node {
+	var r *html.Node
+	walkNodes(node, func(child *html.Node) bool {
+		if r != nil || child.Type != html.ElementNode {
+			return false
+		}
+		hints := strings.ToLower(getAttribute(child, "class") + " " + getAttribute(child, "itemprop"))
+		for _, hint := range creditHints {
+			if strings.Index(hints, hint) >= 0 {
+				r = child
+				return false
+			}
+		}
+		return true
+	})
+	return r
+}
+
+// Returns the first descendant of node with the specified tag name
+func findElement(node *html.Node, name string) *html.Node {
+	var r *html.Node
+	walkNodes(node, func(child *html.Node) bool {
+		if r == nil && child.Type == html.ElementNode && strings.ToLower(child.Data) == name {
+			r = child
+		}
+		return r == nil
+	})
+	return r
+}
+
+// Returns the text contained in node, excluding suppressed elements and skip
+func nodeText(node *html.Node, skip *html.Node) string {
+	var out []rune
+
+	// visited without recursion, block elements are surrounded by spaces
+	type frame struct {
+		next  *html.Node // next child to visit
+		space bool       // a space is added after the last child
+	}
+	stack := []frame{{next: node.FirstChild}}
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		child := top.next
+		if child == nil {
+			if top.space {
+				out = append(out, ' ')
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		top.next = child.NextSibling
+
+		switch child.Type {
+		case html.TextNode:
+			out = append(out, []rune(child.Data)...)
+		case html.ElementNode:
+			if child == skip {
+				continue
+			}
+			switch getNodeKind(child) {
+			case _K_SUPPRESSED:
+				out = append(out, ' ')
+			case _K_INLINE, _K_FORMATTING:
+				stack = append(stack, frame{next: child.FirstChild})
+			default:
+				out = append(out, ' ')
+				stack = append(stack, frame{next: child.FirstChild, space: true})
+			}
+		}
+	}
+	return strings.TrimSpace(string(cleanControl(collapseWhitespace(out))))
+}
+
+// Returns the figures of a cleaned tree
+func collectFigures(cleaned *element) []*Figure {
+	r := []*Figure{}
+	walkElements(cleaned, func(e *element) {
+		if e.figure != nil {
+			r = append(r, e.figure)
+		}
+	})
+	return r
+}
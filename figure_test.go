@@ -0,0 +1,59 @@
+package sandblast
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+const figureTestDoc = `<html><body>
+<p>This is the first paragraph of the article, long enough to be kept as text.</p>
+<figure><img src="data:image/gif;base64,R0lG" data-src="/images/bridge.jpg" alt="A bridge">
+<figcaption>The new bridge at sunset. <span class="credit">Photo: Jane Doe</span></figcaption></figure>
+<p>This is the second paragraph of the article, long enough to be kept as text.</p>
+<table><caption>Results</caption>
+<tr><td>The first row of the table contains a long description</td><td>10</td></tr>
+<tr><td>The second row of the table contains a long description</td><td>20</td></tr>
+</table>
+</body></html>`
+
+func TestFigures(t *testing.T) {
+	node, err := html.Parse(strings.NewReader(figureTestDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ExtractResult(node, KeepImages)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Figures) != 1 {
+		t.Fatalf("Wrong number of figures %d", len(r.Figures))
+	}
+	f := r.Figures[0]
+	if f.Src != "/images/bridge.jpg" || f.Alt != "A bridge" || f.Caption != "The new bridge at sunset." || f.Credit != "Photo: Jane Doe" {
+		t.Errorf("Wrong figure %#v", f)
+	}
+
+	tgt := []string{
+		"This is the first paragraph of the article, long enough to be kept as text.",
+		"The new bridge at sunset. (Photo: Jane Doe) [img: /images/bridge.jpg]",
+		"This is the second paragraph of the article, long enough to be kept as text.",
+		"Results",
+		"The first row of the table contains a long description 10",
+		"The second row of the table contains a long description 20",
+	}
+	lines := []string{}
+	for _, line := range strings.Split(r.Text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if strings.Join(lines, "\n") != strings.Join(tgt, "\n") {
+		t.Errorf("Wrong text\n\tgot:\n%s\n\texpected:\n%s\n", strings.Join(lines, "\n"), strings.Join(tgt, "\n"))
+	}
+
+	if len(r.Paragraphs) != 6 || r.Paragraphs[1].Figure != f || !r.Paragraphs[3].Caption {
+		t.Errorf("Wrong paragraphs %#v", r.Paragraphs)
+	}
+}
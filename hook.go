@@ -0,0 +1,83 @@
+package sandblast
+
+import (
+	"strings"
+)
+
+// Information about a block of the document, passed to a BlockHook and returned by BlockSet.Block
+type Block struct {
+	Kind        string  // One of "textblock", "header", "caption", "figure", "media", "linkblob" or "linklist"
+	Text        string  // Text of the block, a hook can change it to rewrite the block
+	LinkDensity float32 // Fraction of the text of the block that is inside links
+	Heading     int     // Level of the heading (1 to 6) for "header" blocks, 0 otherwise
+	Prev, Next  *Block  // Neighboring blocks, nil at the start and end of the document
+
+	original string
+}
+
+// Decision of a BlockHook about a block
+type BlockAction int
+
+const (
+	DefaultBlock = BlockAction(iota) // The block is kept or dropped according to the default rules
+	KeepBlock                        // The block is kept regardless of the default rules
+	DropBlock                        // The block is dropped
+)
+
+// A BlockHook is called on every block of the document before it is cleaned
+type BlockHook interface {
+	Block(b *Block) BlockAction
+}
+
+// Adapter to use an ordinary function as a BlockHook
+type BlockHookFunc func(b *Block) BlockAction
+
+func (fn BlockHookFunc) Block(b *Block) BlockAction {
+	return fn(b)
+}
+
+// Returns the descriptions of blocks, linked to their neighbors, nil for nil blocks
+func blockViews(blocks []*element) []*Block {
+	bs := make([]*Block, len(blocks))
+	var prev *Block
+	for i, e := range blocks {
+		if e == nil {
+			continue
+		}
+		text := blockText(e)
+		bs[i] = &Block{Kind: strings.TrimPrefix(e.tag, "~"), Text: text, LinkDensity: e.linkPart, Heading: e.headingLevel(), Prev: prev, original: text}
+		if prev != nil {
+			prev.Next = bs[i]
+		}
+		prev = bs[i]
+	}
+	return bs
+}
+
+// Calls hook on every element of blocks, returns the actions decided by the hook.
+// Blocks whose text was changed by the hook are rewritten.
+func runHook(hook BlockHook, blocks []*element) []BlockAction {
+	actions := make([]BlockAction, len(blocks))
+	if hook == nil {
+		return actions
+	}
+
+	bs := blockViews(blocks)
+	for i, b := range bs {
+		if b == nil {
+			continue
+		}
+		actions[i] = hook.Block(b)
+		if b.Text != b.original {
+			e := blocks[i]
+			e.content = b.Text
+			e.childs = nil
+			e.hrefs = nil
+			e.linkPart = b.LinkDensity
+			if e.tag == "~linklist" || e.tag == "~linkblob" {
+				e.tag = "~textblock"
+			}
+		}
+	}
+	return actions
+}
@@ -0,0 +1,58 @@
+package sandblast
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestBlockHook(t *testing.T) {
+	const doc = `<html><body>
+<p>This is the first paragraph of the article, long enough to be kept as text.</p>
+<p>Advertisement: this paragraph is long enough to be kept but it is an ad.</p>
+<p>This is the last paragraph of the article, long enough to be kept as text.</p>
+<div>Menu</div><div>Home</div><div>$AAPL</div>
+<p>Some footer text</p>
+</body></html>`
+
+	node, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := 0
+	hook := BlockHookFunc(func(b *Block) BlockAction {
+		seen++
+		switch {
+		case strings.HasPrefix(b.Text, "Advertisement:"):
+			return DropBlock
+		case strings.HasPrefix(b.Text, "$"):
+			return KeepBlock
+		case strings.HasPrefix(b.Text, "This is the last"):
+			if b.Prev == nil || b.Next == nil || b.Next.Text != "Menu" {
+				t.Errorf("Wrong neighbors for %q", b.Text)
+			}
+			b.Text = "Rewritten paragraph."
+			return KeepBlock
+		}
+		return DefaultBlock
+	})
+
+	r, err := ExtractWithOptions(node, &Options{Hook: hook})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if seen != 7 {
+		t.Errorf("Hook called on %d blocks", seen)
+	}
+
+	out := []string{}
+	for _, p := range r.Paragraphs {
+		out = append(out, p.Text)
+	}
+	tgt := []string{"This is the first paragraph of the article, long enough to be kept as text.", "Rewritten paragraph.", "$AAPL"}
+	if strings.Join(out, "\n") != strings.Join(tgt, "\n") {
+		t.Errorf("Wrong paragraphs\n\tgot:\n%s\n\texpected:\n%s\n", strings.Join(out, "\n"), strings.Join(tgt, "\n"))
+	}
+}
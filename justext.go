@@ -0,0 +1,183 @@
+package sandblast
+
+// Classes of blocks for the stopword density classifier
+type blockClass int
+
+const (
+	_CLASS_BAD blockClass = iota
+	_CLASS_SHORT
+	_CLASS_NEARGOOD
+	_CLASS_GOOD
+)
+
+func (c blockClass) String() string {
+	switch c {
+	case _CLASS_BAD:
+		return "bad"
+	case _CLASS_SHORT:
+		return "short"
+	case _CLASS_NEARGOOD:
+		return "near-good"
+	case _CLASS_GOOD:
+		return "good"
+	}
+	return "unknown"
+}
+
+// Thresholds of the stopword density classifier, lengths are for the default language profile and are scaled for the others
+const (
+	_JT_LENGTH_LOW       = 70
+	_JT_LENGTH_HIGH      = 200
+	_JT_STOPWORDS_LOW    = 0.30
+	_JT_STOPWORDS_HIGH   = 0.32
+	_JT_MAX_LINK_DENSITY = 0.2
+	_JT_MAX_HEADING_DIST = 200
+)
+
+// Features of a block used by the stopword density classifier
+type blockStats struct {
+	length      int
+	linkDensity float64
+	stopwords   float64 // fraction of words that are stopwords
+}
+
+func newBlockStats(e *element, profile *languageProfile) blockStats {
+	text := blockText(e)
+	r := blockStats{length: textLength(text), linkDensity: float64(e.linkPart)}
+	words := splitWords(text)
+	if len(words) > 0 {
+		n := 0
+		for _, w := range words {
+			if profile.isStopword(w) {
+				n++
+			}
+		}
+		r.stopwords = float64(n) / float64(len(words))
+	}
+	return r
+}
+
+// Classifies the blocks with the algorithm of jusText: blocks are first classified as good, near-good, short or bad
+// by their length, link density and stopword density, then short and near-good blocks are reclassified according to their neighbors.
+// Blocks already dropped are bad.
+func classifyStopwords(s *BlockSet) {
+	blocks, profile := s.parent.childs, s.profile
+	if profile == nil {
+		profile = defaultProfile
+	}
+	scale := float64(profile.okLength) / float64(defaultProfile.okLength)
+	lengthLow, lengthHigh := int(_JT_LENGTH_LOW*scale), int(_JT_LENGTH_HIGH*scale)
+	stopwordsLow, stopwordsHigh := _JT_STOPWORDS_LOW, _JT_STOPWORDS_HIGH
+	if profile.stopwords == nil {
+		// without stopwords blocks are classified by length and link density only
+		stopwordsLow, stopwordsHigh = 0, 0
+	}
+
+	stats := make([]blockStats, len(blocks))
+	classes := make([]blockClass, len(blocks))
+	for i, block := range blocks {
+		if block == nil {
+			classes[i] = _CLASS_BAD
+			continue
+		}
+		stats[i] = newBlockStats(block, profile)
+		st := stats[i]
+		switch {
+		case block.tag == "~header":
+			classes[i] = _CLASS_SHORT
+		case st.linkDensity > _JT_MAX_LINK_DENSITY:
+			classes[i] = _CLASS_BAD
+		case st.length < lengthLow:
+			if st.linkDensity > 0 {
+				classes[i] = _CLASS_BAD
+			} else {
+				classes[i] = _CLASS_SHORT
+			}
+		case st.stopwords >= stopwordsHigh:
+			if st.length > lengthHigh {
+				classes[i] = _CLASS_GOOD
+			} else {
+				classes[i] = _CLASS_NEARGOOD
+			}
+		case st.stopwords >= stopwordsLow:
+			classes[i] = _CLASS_NEARGOOD
+		default:
+			classes[i] = _CLASS_BAD
+		}
+	}
+	initial := make([]blockClass, len(classes))
+	copy(initial, classes)
+
+	// headings shortly before good text are near-good
+	for i, block := range blocks {
+		if block == nil || block.tag != "~header" {
+			continue
+		}
+		dist := 0
+		for j := i + 1; j < len(blocks) && dist <= int(_JT_MAX_HEADING_DIST*scale); j++ {
+			if initial[j] == _CLASS_GOOD {
+				classes[i] = _CLASS_NEARGOOD
+				break
+			}
+			dist += stats[j].length
+		}
+	}
+
+	// neighbor returns the class of the closest block before (dir < 0) or after (dir > 0) i that isn't short (or near-good), bad at the edges
+	neighbor := func(classes []blockClass, i, dir int, ignoreNeargood bool) blockClass {
+		for j := i + dir; j >= 0 && j < len(classes); j += dir {
+			if classes[j] == _CLASS_SHORT || (ignoreNeargood && classes[j] == _CLASS_NEARGOOD) {
+				continue
+			}
+			return classes[j]
+		}
+		return _CLASS_BAD
+	}
+
+	revised := make([]blockClass, len(classes))
+	copy(revised, classes)
+	for i := range classes {
+		if classes[i] != _CLASS_SHORT {
+			continue
+		}
+		prev, next := neighbor(classes, i, -1, true), neighbor(classes, i, +1, true)
+		switch {
+		case prev == _CLASS_GOOD && next == _CLASS_GOOD:
+			revised[i] = _CLASS_GOOD
+		case prev == _CLASS_BAD && next == _CLASS_BAD:
+			revised[i] = _CLASS_BAD
+		case (prev == _CLASS_BAD && neighbor(classes, i, -1, false) == _CLASS_NEARGOOD) || (next == _CLASS_BAD && neighbor(classes, i, +1, false) == _CLASS_NEARGOOD):
+			revised[i] = _CLASS_GOOD
+		default:
+			revised[i] = _CLASS_BAD
+		}
+	}
+	classes = revised
+
+	for i := range classes {
+		if classes[i] != _CLASS_NEARGOOD {
+			continue
+		}
+		if neighbor(classes, i, -1, true) == _CLASS_BAD && neighbor(classes, i, +1, true) == _CLASS_BAD {
+			classes[i] = _CLASS_BAD
+		} else {
+			classes[i] = _CLASS_GOOD
+		}
+	}
+
+	for i, block := range blocks {
+		if s.Decided(i) || block.span > 0 {
+			continue
+		}
+		st := stats[i]
+		if classes[i] == _CLASS_BAD && initial[i] == _CLASS_BAD {
+			s.Drop(i, "bad block (length %d, link density %.2f, stopword density %.2f)", st.length, st.linkDensity, st.stopwords)
+		} else if classes[i] == _CLASS_BAD {
+			s.Drop(i, "%s block reclassified as bad (length %d, link density %.2f, stopword density %.2f)", initial[i], st.length, st.linkDensity, st.stopwords)
+		} else if initial[i] == _CLASS_GOOD {
+			s.Keep(i, "good block")
+		} else {
+			s.Keep(i, "%s block reclassified as good", initial[i])
+		}
+	}
+}
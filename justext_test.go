@@ -0,0 +1,48 @@
+package sandblast
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestStopwordDensity(t *testing.T) {
+	tf := func(doc string, target string) {
+		node, err := html.Parse(strings.NewReader(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, _ := ExtractWithOptions(node, &Options{Extractor: JusTextExtractor})
+		if out := strings.TrimSpace(string(collapseWhitespace([]rune(r.Text)))); out != target {
+			t.Errorf("Error extracting <%s>\n\tgot <%s>\n\texpected <%s>\n", doc, out, target)
+		}
+	}
+	const good1 = "The city council met on Tuesday evening to discuss the plan for the new bridge, which has been delayed for more than two years because of a long dispute between the city and the company that won the contract to build it."
+	const good2 = "According to the mayor, the work will start again in the spring and the bridge should be open to traffic by the end of next year, if the weather is not too bad and there are no other problems with the company."
+	const forum = "Posted by admin Reply Quote Report Permalink"
+
+	// short blocks between good blocks are kept, headings before good text are kept
+	tf("<html lang='en'><body><h2>The new bridge</h2><p>"+good1+"</p><p>It was a long meeting.</p><p>"+good2+"</p></body></html>",
+		"The new bridge "+good1+" It was a long meeting. "+good2)
+	// blocks without stopwords are bad, short blocks between bad blocks are bad
+	tf("<html lang='en'><body><p>"+good1+"</p><div>"+forum+"</div><div>Join date: 2010</div><div>"+forum+"</div></body></html>", good1)
+	// near-good blocks next to good blocks are kept
+	tf("<html lang='en'><body><p>"+good1+"</p><p>The council will vote on the new plan in June, after a public meeting with the people of the city.</p></body></html>", good1+" The council will vote on the new plan in June, after a public meeting with the people of the city.")
+}
+
+func TestStopwordDensityExplain(t *testing.T) {
+	node, err := html.Parse(strings.NewReader("<html lang='en'><body><p>Reply Quote Report Permalink Share</p></body></html>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _ := ExtractWithOptions(node, &Options{Flags: Explain, Extractor: JusTextExtractor})
+	for _, d := range r.Log {
+		if d.Stage == "clean" {
+			if !d.Dropped || !strings.HasPrefix(d.Reason, "short block reclassified as bad") {
+				t.Errorf("Wrong decision %v %q", d.Dropped, d.Reason)
+			}
+			return
+		}
+	}
+	t.Errorf("No clean decision")
+}
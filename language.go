@@ -0,0 +1,316 @@
+package sandblast
+
+import (
+	"golang.org/x/net/html"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Thresholds and stopwords used by the clean stage for a language
+type languageProfile struct {
+	shortLength int             // textblocks up to this length (in characters) are dropped
+	okLength    int             // textblocks longer than this (in characters) are ok text
+	stopwords   map[string]bool // can be nil
+}
+
+var defaultProfile = &languageProfile{shortLength: 15, okLength: 50}
+
+// Stopwords of each language, most frequent first.
+// For Chinese and Japanese every character is a word (see splitWords) so their stopwords are single characters.
+var stopwordLists = map[string]string{
+	"en": "the of and to a in is it that was for on are with as i his he be at by this had not but from or have an they which you were her she there would their we him been has when who will more no if out so said what up its about into than them can only other new some could these two may then do first any my now such like our over me even most made after also did many before must through back where much your way well down should because each just those how too very",
+	"de": "der die und in den von zu das mit sich des auf für ist im dem nicht ein eine als auch es an werden aus er hat dass sie nach wird bei einer um am sind noch wie einem über einen so zum war haben nur oder aber vor zur bis mehr durch man sein wurde sei ich wir ihr ihre kann wenn schon wieder diese dieser gegen vom können hatte unter sehr",
+	"fr": "de la le et les des en un du une que est pour qui dans a par plus pas au sur ne se ce il sont avec son été ou mais elle leur aux y comme ont on cette nous sa tout ses lui bien fait sans peut entre ces deux aussi être même très après",
+	"es": "de la que el en y a los del se las por un para con no una su al lo como más pero sus le ya o este porque esta entre cuando muy sin sobre también me hasta hay donde quien desde todo nos durante todos uno les ni contra otros ese eso ante ellos e esto antes algunos qué unos yo otro otras otra él tanto esa estos mucho nada muchos cual poco ella estar estas algo es fue ha son",
+	"it": "di e il la che in a per un è del non si le una i con da sono al dei della alla più ma come anche nel lo gli ha delle ci questo su se ne tra o suo sua loro dal nella essere cui già molto stato quando degli fatto era tutti questa",
+	"pt": "de a o que e do da em um para é com não uma os no se na por mais as dos como mas foi ao ele das tem à seu sua ou ser quando muito há nos já está eu também só pelo pela até isso ela entre era depois sem mesmo aos ter seus quem nas me esse eles estão você tinha foram essa num nem suas meu às minha têm numa pelos elas havia seja qual será nós",
+	"nl": "de en van het een in is dat op te zijn met voor niet die aan er ook als maar om bij dan of wordt door nog naar uit worden heeft was hij ze tot werd kan deze we wat hebben al over meer zo geen wel moet ik u haar hun dit zich",
+	"sv": "och i att det som en på är av för med till den har de inte om ett han men var jag sig från vi så kan man när år säger hon under också efter eller nu sin där vid mot ska skulle kommer ut får finns vara hade alla andra mycket än här då sedan över bara",
+	"pl": "i w na z do że się nie to jest o jak a po co tak za od jego ale przez czy dla już tylko jej są był może było roku jako oraz lub przy pod które który która ich także bardzo tym gdy",
+	"ru": "и в не на что с он как по это к но из у за от о так для же все она бы был только было его мы ты вы до когда уже или ни быть если тоже при их чтобы еще сказал есть ее можно этот этого том после этом также",
+	"ja": "の に は を た が で て と し れ さ も な か へ や だ す ま",
+	"zh": "的 了 是 在 和 有 我 他 这 也 就 不 人 都 一 上 们 到 说 中 个 为 与 对 以 等 将 之 而",
+}
+
+// Samples of ordinary prose of the languages detected from their character trigrams, see detectLanguage
+var languageSamples = map[string]string{
+	"en": "All human beings are born free and equal in dignity and rights. They are endowed with reason and conscience and should act towards one another in a spirit of brotherhood. " +
+		"Everyone has the right to life, liberty and security of person. The weather was cold this morning, but in the afternoon the sun came out and the children went to play in the park near the river. " +
+		"Prices have been rising for months, and many families say they can no longer afford to heat their homes through the winter.",
+	"de": "Alle Menschen sind frei und gleich an Würde und Rechten geboren. Sie sind mit Vernunft und Gewissen begabt und sollen einander im Geist der Brüderlichkeit begegnen. " +
+		"Jeder hat das Recht auf Leben, Freiheit und Sicherheit der Person. Das Wetter war heute Morgen kalt, aber am Nachmittag kam die Sonne heraus und die Kinder gingen im Park am Fluss spielen. " +
+		"Die Preise steigen seit Monaten, und viele Familien sagen, dass sie sich die Heizung im Winter nicht mehr leisten können.",
+	"fr": "Tous les êtres humains naissent libres et égaux en dignité et en droits. Ils sont doués de raison et de conscience et doivent agir les uns envers les autres dans un esprit de fraternité. " +
+		"Tout individu a droit à la vie, à la liberté et à la sûreté de sa personne. Il faisait froid ce matin, mais l'après-midi le soleil est sorti et les enfants sont allés jouer dans le parc près de la rivière. " +
+		"Les prix augmentent depuis des mois et beaucoup de familles disent qu'elles ne peuvent plus payer le chauffage pendant l'hiver.",
+	"es": "Todos los seres humanos nacen libres e iguales en dignidad y derechos y, dotados como están de razón y conciencia, deben comportarse fraternalmente los unos con los otros. " +
+		"Todo individuo tiene derecho a la vida, a la libertad y a la seguridad de su persona. Esta mañana hacía frío, pero por la tarde salió el sol y los niños fueron a jugar al parque junto al río. " +
+		"Los precios llevan meses subiendo y muchas familias dicen que ya no pueden pagar la calefacción durante el invierno.",
+	"it": "Tutti gli esseri umani nascono liberi ed eguali in dignità e diritti. Essi sono dotati di ragione e di coscienza e devono agire gli uni verso gli altri in spirito di fratellanza. " +
+		"Ogni individuo ha diritto alla vita, alla libertà ed alla sicurezza della propria persona. Stamattina faceva freddo, ma nel pomeriggio è uscito il sole e i bambini sono andati a giocare nel parco vicino al fiume. " +
+		"I prezzi salgono da mesi e molte famiglie dicono che non riescono più a pagare il riscaldamento durante l'inverno.",
+	"pt": "Todos os seres humanos nascem livres e iguais em dignidade e em direitos. Dotados de razão e de consciência, devem agir uns para com os outros em espírito de fraternidade. " +
+		"Todo o indivíduo tem direito à vida, à liberdade e à segurança pessoal. Esta manhã estava frio, mas à tarde o sol apareceu e as crianças foram brincar no parque perto do rio. " +
+		"Os preços estão a subir há meses e muitas famílias dizem que já não conseguem pagar o aquecimento durante o inverno.",
+	"nl": "Alle mensen worden vrij en gelijk in waardigheid en rechten geboren. Zij zijn begiftigd met verstand en geweten, en behoren zich jegens elkander in een geest van broederschap te gedragen. " +
+		"Een ieder heeft het recht op leven, vrijheid en onschendbaarheid van zijn persoon. Het was vanochtend koud, maar in de middag kwam de zon tevoorschijn en gingen de kinderen spelen in het park bij de rivier. " +
+		"De prijzen stijgen al maanden en veel gezinnen zeggen dat ze de verwarming in de winter niet meer kunnen betalen.",
+	"sv": "Alla människor är födda fria och lika i värde och rättigheter. De är utrustade med förnuft och samvete och bör handla gentemot varandra i en anda av broderskap. " +
+		"Var och en har rätt till liv, frihet och personlig säkerhet. Det var kallt i morse, men på eftermiddagen kom solen fram och barnen gick och lekte i parken vid ån. " +
+		"Priserna har stigit i flera månader och många familjer säger att de inte längre har råd att värma sina hem under vintern.",
+	"pl": "Wszyscy ludzie rodzą się wolni i równi pod względem swej godności i swych praw. Są oni obdarzeni rozumem i sumieniem i powinni postępować wobec innych w duchu braterstwa. " +
+		"Każdy człowiek ma prawo do życia, wolności i bezpieczeństwa swojej osoby. Dziś rano było zimno, ale po południu wyszło słońce i dzieci poszły bawić się w parku nad rzeką. " +
+		"Ceny rosną od miesięcy i wiele rodzin mówi, że nie stać ich już na ogrzewanie domu przez zimę.",
+	"ru": "Все люди рождаются свободными и равными в своем достоинстве и правах. Они наделены разумом и совестью и должны поступать в отношении друг друга в духе братства. " +
+		"Каждый человек имеет право на жизнь, на свободу и на личную неприкосновенность. Утром было холодно, но днем выглянуло солнце, и дети пошли играть в парк у реки. " +
+		"Цены растут уже несколько месяцев, и многие семьи говорят, что больше не могут платить за отопление зимой.",
+}
+
+// Clean stage profiles of the languages that need thresholds different from the default ones:
+// languages with long compound words need longer blocks, Chinese and Japanese need shorter ones.
+var languageProfiles = map[string]*languageProfile{
+	"de": {shortLength: 18, okLength: 60},
+	"nl": {shortLength: 17, okLength: 55},
+	"sv": {shortLength: 17, okLength: 55},
+	"ja": {shortLength: 8, okLength: 25},
+	"zh": {shortLength: 6, okLength: 20},
+	"ko": {shortLength: 10, okLength: 35},
+	"th": {shortLength: 12, okLength: 40},
+}
+
+// Trigram vectors of the languages of languageSamples, used by detectLanguage
+var languageTrigrams = map[string]map[string]float64{}
+
+// Languages of languageTrigrams, sorted, and the norms of their vectors
+var (
+	trigramLanguages []string
+	languageNorms    = map[string]float64{}
+)
+
+func init() {
+	for lang, list := range stopwordLists {
+		stopwords := map[string]bool{}
+		for _, w := range strings.Fields(list) {
+			stopwords[w] = true
+		}
+		profile := languageProfiles[lang]
+		if profile == nil {
+			profile = &languageProfile{shortLength: defaultProfile.shortLength, okLength: defaultProfile.okLength}
+			languageProfiles[lang] = profile
+		}
+		profile.stopwords = stopwords
+	}
+	for lang, sample := range languageSamples {
+		words := append(splitWords(strings.ToLower(sample)), strings.Fields(stopwordLists[lang])...)
+		vector := trigrams(words)
+		keys := make([]string, 0, len(vector))
+		for t, n := range vector {
+			vector[t] = 1 + math.Log(n) // frequent trigrams are shared by many languages
+			keys = append(keys, t)
+		}
+		sort.Strings(keys)
+		norm := 0.0
+		for _, t := range keys {
+			norm += vector[t] * vector[t]
+		}
+		languageTrigrams[lang], languageNorms[lang] = vector, math.Sqrt(norm)
+		trigramLanguages = append(trigramLanguages, lang)
+	}
+	sort.Strings(trigramLanguages)
+}
+
+// Returns the clean stage profile of lang, the default profile if lang is unknown
+func profileFor(lang string) *languageProfile {
+	if profile := languageProfiles[lang]; profile != nil {
+		return profile
+	}
+	return defaultProfile
+}
+
+func (p *languageProfile) isStopword(word string) bool {
+	return p != nil && p.stopwords[strings.ToLower(word)]
+}
+
+// Returns true if e is a textblock long enough to be considered good text, p can be nil
+func (p *languageProfile) okText(e *element) bool {
+	if p == nil {
+		p = defaultProfile
+	}
+	return e != nil && e.tag == "~textblock" && textLength(e.content) > p.okLength
+}
+
+// Returns the language of the document (as a lower case ISO 639-1 code) and where it was found:
+// "lang" (the lang attribute of the root or one of its ancestors), "content-language" (the Content-Language meta header),
+// "text" (detected from the text of the simplified tree) or "" if it couldn't be determined
+func findLanguage(roots []*html.Node, simplified *element) (lang, source string) {
+	for _, root := range roots {
+		for node := root; node != nil; node = node.Parent {
+			if node.Type != html.ElementNode {
+				continue
+			}
+			if lang := normalizeLanguage(getAttribute(node, "lang")); lang != "" {
+				return lang, "lang"
+			}
+			if lang := normalizeLanguage(getAttribute(node, "xml:lang")); lang != "" {
+				return lang, "lang"
+			}
+		}
+	}
+
+	for _, root := range roots {
+		content := ""
+		forEachElement(root, "meta", func(meta *html.Node) {
+			if content == "" && strings.ToLower(getAttribute(meta, "http-equiv")) == "content-language" {
+				content = getAttribute(meta, "content")
+			}
+		})
+		if lang := normalizeLanguage(content); lang != "" {
+			return lang, "content-language"
+		}
+	}
+
+	if lang := detectLanguage(sampleText(simplified, 4000)); lang != "" {
+		return lang, "text"
+	}
+	return "", ""
+}
+
+// Returns the primary subtag of a language tag ("en-US" is "en"), the first one if s is a list
+func normalizeLanguage(s string) string {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if i := strings.IndexAny(s, ",;"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	if i := strings.IndexAny(s, "-_"); i >= 0 {
+		s = s[:i]
+	}
+	if len(s) < 2 || len(s) > 3 {
+		return ""
+	}
+	for _, ch := range s {
+		if ch < 'a' || ch > 'z' {
+			return ""
+		}
+	}
+	return s
+}
+
+// Returns about max bytes of the text of e
+func sampleText(e *element, max int) string {
+	if e == nil {
+		return ""
+	}
+	var out strings.Builder
+	stack := []*element{e}
+	for len(stack) > 0 && out.Len() < max {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if e.childs == nil {
+			var lctxt linkContext
+			out.WriteString(lctxt.convertLinks(e.content, false))
+			out.WriteByte(' ')
+			continue
+		}
+		for i := len(e.childs) - 1; i >= 0; i-- {
+			if e.childs[i] != nil {
+				stack = append(stack, e.childs[i])
+			}
+		}
+	}
+	return out.String()
+}
+
+// Scripts that identify a language on their own
+var languageScripts = []struct {
+	lang   string
+	script *unicode.RangeTable
+}{
+	{"ko", unicode.Hangul},
+	{"th", unicode.Thai},
+	{"el", unicode.Greek},
+	{"he", unicode.Hebrew},
+	{"ar", unicode.Arabic},
+	{"hi", unicode.Devanagari},
+	{"hy", unicode.Armenian},
+	{"ka", unicode.Georgian},
+}
+
+// Detects the language of text from its script or, for the scripts used by several languages,
+// from the similarity of its character trigrams with the trigrams of a sample of each language (ties go to the first language in alphabetical order).
+// Returns "" if the language can't be determined.
+func detectLanguage(text string) string {
+	letters, kana, han := 0, 0, 0
+	scripts := make([]int, len(languageScripts))
+	for _, ch := range text {
+		if !unicode.IsLetter(ch) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.In(ch, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, ch):
+			han++
+		default:
+			for i := range languageScripts {
+				if unicode.Is(languageScripts[i].script, ch) {
+					scripts[i]++
+					break
+				}
+			}
+		}
+	}
+	if letters < 10 {
+		return ""
+	}
+
+	switch {
+	case kana > 0 && (kana+han)*10 >= letters*3:
+		return "ja"
+	case han*10 >= letters*3:
+		return "zh"
+	}
+	for i := range languageScripts {
+		if scripts[i]*10 >= letters*3 {
+			return languageScripts[i].lang
+		}
+	}
+
+	words := splitWords(strings.ToLower(text))
+	if len(words) < 5 {
+		return ""
+	}
+	doc := trigrams(words)
+	keys := make([]string, 0, len(doc))
+	for t := range doc {
+		keys = append(keys, t)
+	}
+	sort.Strings(keys) // the sums below don't depend on the order of the map
+	best, bestScore := "", 0.0
+	for _, lang := range trigramLanguages {
+		vector := languageTrigrams[lang]
+		dot := 0.0
+		for _, t := range keys {
+			dot += vector[t] * doc[t]
+		}
+		// the norm of doc is the same for every language
+		if score := dot / languageNorms[lang]; score > bestScore {
+			best, bestScore = lang, score
+		}
+	}
+	return best
+}
+
+// Returns the frequencies of the character trigrams of words, padded with spaces
+func trigrams(words []string) map[string]float64 {
+	r := map[string]float64{}
+	for _, w := range words {
+		rs := []rune(" " + w + " ")
+		for i := 0; i+3 <= len(rs); i++ {
+			r[string(rs[i:i+3])]++
+		}
+	}
+	return r
+}
@@ -0,0 +1,63 @@
+package sandblast
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tf := func(in string, target string) {
+		out := detectLanguage(in)
+		if out != target {
+			t.Errorf("Error detecting language of <%s>\n\tgot <%s>\n\texpected <%s>\n", in, out, target)
+		}
+	}
+	tf("The government announced on Tuesday that the new bridge would be opened to traffic by the end of the year.", "en")
+	tf("Die Regierung hat am Dienstag angekündigt, dass die neue Brücke bis zum Ende des Jahres für den Verkehr geöffnet wird.", "de")
+	tf("Le gouvernement a annoncé mardi que le nouveau pont serait ouvert à la circulation avant la fin de l'année.", "fr")
+	tf("El gobierno anunció el martes que el nuevo puente se abrirá al tráfico antes de que termine el año.", "es")
+	tf("Il governo ha annunciato martedì che il nuovo ponte sarà aperto al traffico entro la fine dell'anno.", "it")
+	tf("De regering heeft dinsdag aangekondigd dat de nieuwe brug voor het einde van het jaar voor het verkeer wordt geopend.", "nl")
+	tf("Правительство объявило во вторник, что новый мост будет открыт для движения до конца года.", "ru")
+	tf("O governo anunciou na terça-feira que a nova ponte será aberta ao trânsito até ao fim do ano.", "pt")
+	tf("Regeringen meddelade på tisdagen att den nya bron ska öppnas för trafik före årets slut.", "sv")
+	tf("Rząd ogłosił we wtorek, że nowy most zostanie otwarty dla ruchu do końca roku.", "pl")
+	tf("政府は火曜日、新しい橋を年内に開通させると発表した。", "ja")
+	tf("政府周二宣布，新桥将在年底前通车。", "zh")
+	tf("정부는 화요일 새 다리가 연말까지 개통될 것이라고 발표했다.", "ko")
+	tf("Too short", "")
+}
+
+func TestFindLanguage(t *testing.T) {
+	tf := func(doc string, target, targetSource string) {
+		node, err := html.Parse(strings.NewReader(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, _ := ExtractResult(node, 0)
+		if r.Language != target || r.LanguageSource != targetSource {
+			t.Errorf("Error finding language of <%s>\n\tgot <%s %s>\n\texpected <%s %s>\n", doc, r.Language, r.LanguageSource, target, targetSource)
+		}
+	}
+	const body = "<body><p>This is the first paragraph of the article, it is long enough to be kept.</p></body>"
+	tf("<html lang='en-GB'>"+body+"</html>", "en", "lang")
+	tf("<html><head><meta http-equiv='Content-Language' content='de, en'></head>"+body+"</html>", "de", "content-language")
+	tf("<html>"+body+"</html>", "en", "text")
+}
+
+func TestLanguageProfiles(t *testing.T) {
+	tf := func(doc string, target string) {
+		node, err := html.Parse(strings.NewReader(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, _ := ExtractResult(node, 0)
+		if out := strings.TrimSpace(r.Text); out != target {
+			t.Errorf("Error extracting <%s>\n\tgot <%s>\n\texpected <%s>\n", doc, out, target)
+		}
+	}
+	// shorter than the default threshold of ok text, but long enough for Japanese
+	tf("<html lang='ja'><body><p>政府は火曜日、新しい橋を年内に開通させると発表した。</p></body></html>", "政府は火曜日、新しい橋を年内に開通させると発表した。")
+	tf("<html lang='en'><body><p>政府は火曜日、新しい橋を年内に開通させると発表した。</p></body></html>", "")
+}
@@ -2,57 +2,268 @@ package sandblast
 
 import (
 	"bytes"
-	"fmt"
 	"golang.org/x/net/html"
 	"strings"
 )
 
-func extractEx(node *html.Node, flags Flags) (title, text string, simplified, flattened, cleaned *element, err error) {
-	root := findRoot(node)
-	if root == nil {
-		err = fmt.Errorf("Could not find root")
+// The result of extracting text from a document
+type Result struct {
+	Title   string      // Title of the document, without the name of the site
+	Text    string      // Extracted text
+	Outline *Section    // Extracted text divided into sections by its headings
+	TOC     []*TOCEntry // Table of contents built from the headings of the extracted text
+
+	Paragraphs []*Paragraph // Extracted text divided into paragraphs
+	Figures    []*Figure    // Figures of the extracted text
+	Media      []*Media     // Media embedded in the extracted text
+
+	TitleSource string // Where the title was found: "title" (the title element), "og:title" (the og:title meta property), "h1" (the first h1 heading of the extracted text) or ""
+	Excerpt     string // Description of the document or, if it doesn't have one, its first paragraph, trimmed to Options.ExcerptLength
+
+	Language       string // Language of the document as a lower case ISO 639-1 code, "" if unknown
+	LanguageSource string // Where the language was found: "lang" (the lang attribute), "content-language" (the Content-Language meta header), "text" (detected from the text) or "" if it was set by Options.Language or unknown
+
+	Summary []string // The most important sentences of the extracted text in document order, only set if Options.SummarySentences is greater than zero
+	Stats   *Stats   // Reading statistics of the extracted text
+
+	Confidence float64 // Confidence, between 0 and 1, that the document is an article and the extracted text is its content
+
+	PageType       PageType // Type of the page: article, listing, forum, product, documentation, error or other
+	PageTypeSource string   // Where the type was found: "ld+json" (JSON-LD structured data), "microdata" (itemtype attributes), "og:type" (the og:type meta property), "text" (the blocks of the document) or "" if it is PageOther
+
+	Log       []*Decision // Decisions taken during the extraction, only recorded when extracting with Explain
+	Truncated bool        // Parts of the document deeper than Options.MaxDepth were dropped
+
+	roots                          []*html.Node
+	simplified, flattened, cleaned *element
+	signals                        *pageSignals
+}
+
+// Options of an extraction
+type Options struct {
+	Flags Flags
+	Hook  BlockHook // Called on every block before cleaning it, can be nil
+
+	// Elements nested deeper than MaxDepth are dropped (and Result.Truncated is set), 0 means no limit
+	MaxDepth int
+
+	// Maximum length of Result.Excerpt in characters, 0 means 300
+	ExcerptLength int
+
+	// Language of the document as an ISO 639-1 code, if empty it is detected.
+	// The language selects the thresholds and the stopwords used to clean the text.
+	Language string
+
+	// Thresholds of the rules used to find link lists and to clean the text, nil means DefaultThresholds
+	Thresholds *Thresholds
+
+	// Algorithm used to decide which blocks are content, nil means DefaultExtractor.
+	// If the extractor has a Validate() error method (like Model) it is called before the extraction, which fails with its error.
+	Extractor Extractor
+
+	// Number of sentences of Result.Summary, 0 disables summarization
+	SummarySentences int
+
+	// Reading speeds used to estimate Result.Stats.ReadingTime, in words per minute for most scripts (0 means 230)
+	// and in characters per minute for scripts written without spaces, like Chinese, Japanese or Thai (0 means 500)
+	WordsPerMinute      int
+	IdeographsPerMinute int
+}
+
+// Returns the error of the Validate method of the extractor, if it has one
+func (opts *Options) validate() error {
+	if v, ok := opts.Extractor.(interface{ Validate() error }); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+func extractEx(node *html.Node, opts *Options) (r *Result, err error) {
+	root, err := findRoot(node)
+	if err != nil {
 		return
 	}
 
-	title = getTitle(root)
-	simplified, flattened, cleaned = extractTextEx(root, flags)
-	if cleaned == nil {
-		text = ""
+	r = extractRoots([]*html.Node{root}, opts)
+	return
+}
+
+func extractRoots(roots []*html.Node, opts *Options) *Result {
+	r := &Result{roots: roots}
+	var log *explainLog
+	if opts.Flags&Explain != 0 {
+		log = &explainLog{}
+	}
+	extractTextEx(r, opts, log)
+	r.build(opts, log)
+	return r
+}
+
+// Fills the result from the cleaned tree
+func (r *Result) build(opts *Options, log *explainLog) {
+	flags := opts.Flags
+	r.Log = log.list()
+	r.Title, r.TitleSource = findTitle(r.roots, r.cleaned)
+	r.PageType, r.PageTypeSource = findPageType(r.roots, r.Title, r.signals, r.Confidence)
+	r.Excerpt = findExcerpt(r.roots, r.cleaned, profileFor(r.Language), opts.ExcerptLength)
+	if r.cleaned == nil {
+		r.Text = ""
 	} else {
-		text = cleaned.String(flags)
+		r.Text = r.cleaned.String(flags)
+	}
+	ids := headingIds(r.cleaned)
+	r.Outline = buildOutline(r.cleaned, ids)
+	r.TOC = buildTOC(r.Outline)
+	r.Paragraphs = buildParagraphs(r.cleaned, flags, ids)
+	r.Figures = collectFigures(r.cleaned)
+	r.Media = collectMedia(r.cleaned)
+	r.Stats = buildStats(r.cleaned, r.Paragraphs, opts)
+	if opts.SummarySentences > 0 {
+		r.Summary = summarize(r.Paragraphs, opts.SummarySentences)
 	}
-	return
 }
 
+// Returns the error that must be returned together with the result, if any
+func (r *Result) partialError(opts *Options) error {
+	if r.Truncated {
+		return &DepthError{opts.MaxDepth}
+	}
+	if strings.TrimSpace(r.Text) == "" {
+		return ErrEmptyContent
+	}
+	return nil
+}
+
+// Like Extract but also returns the intermediate trees of the extraction
 func ExtractEx(node *html.Node, flags Flags) (title, text string, simplified, flattened, cleaned *element, err error) {
-	title, text, simplified, flattened, cleaned, err = extractEx(node, flags)
-	return
+	opts := &Options{Flags: flags}
+	r, err := extractEx(node, opts)
+	if err != nil {
+		return
+	}
+	return r.Title, r.Text, r.simplified, r.flattened, r.cleaned, r.partialError(opts)
 }
 
+// Extracts the title and the text of a document.
+// The title and the text are also returned when the error is ErrEmptyContent or ErrDepthExceeded (see IsPartialError).
 func Extract(node *html.Node, flags Flags) (title, text string, err error) {
-	title, text, _, _, _, err = extractEx(node, flags|isDestructive)
-	return
+	opts := &Options{Flags: flags | isDestructive}
+	r, err := extractEx(node, opts)
+	if err != nil {
+		return
+	}
+	return r.Title, r.Text, r.partialError(opts)
+}
+
+// Like Extract but returns the full result of the extraction.
+// The result is also returned when the error is ErrEmptyContent or ErrDepthExceeded.
+func ExtractResult(node *html.Node, flags Flags) (*Result, error) {
+	return ExtractWithOptions(node, &Options{Flags: flags})
+}
+
+// Like ExtractResult but with options, nil options are the same as the zero Options
+func ExtractWithOptions(node *html.Node, opts *Options) (*Result, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	o := *opts
+	o.Flags |= isDestructive
+	r, err := extractEx(node, &o)
+	if err != nil {
+		return nil, err
+	}
+	return r, r.partialError(&o)
 }
 
-func findRoot(node *html.Node) *html.Node {
+// Like ExtractWithOptions but node can be any node of a document (for example an element selected by the caller), not just the document node.
+// Unless node contains a title element the title of the result is taken from its first h1 heading.
+func ExtractNode(node *html.Node, opts *Options) (*Result, error) {
 	if node == nil {
-		return nil
+		return nil, ErrNoRoot
 	}
 	if node.Type == html.DocumentNode {
-		return findRoot(node.FirstChild)
+		return ExtractWithOptions(node, opts)
 	}
-	for node != nil {
-		if (node.Type == html.ElementNode) && (strings.ToLower(node.Data) == "html") {
-			return node
+	return ExtractNodes([]*html.Node{node}, opts)
+}
+
+// Like ExtractNode for a list of nodes, for example the ones returned by html.ParseFragment
+func ExtractNodes(nodes []*html.Node, opts *Options) (*Result, error) {
+	if len(nodes) == 0 {
+		return nil, ErrNoRoot
+	}
+	for _, node := range nodes {
+		if node == nil {
+			return nil, ErrNoRoot
 		}
-		node = node.NextSibling
 	}
-	return nil
+	if opts == nil {
+		opts = &Options{}
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	o := *opts
+	o.Flags |= isDestructive
+	r := extractRoots(nodes, &o)
+	return r, r.partialError(&o)
 }
 
+// Returns the html element of the document, falling back to its body if it doesn't have one
+func findRoot(node *html.Node) (*html.Node, error) {
+	if node == nil {
+		return nil, ErrNoRoot
+	}
+	if node.Type == html.DocumentNode {
+		node = node.FirstChild
+	} else if node.Type != html.ElementNode {
+		return nil, ErrNotHTML
+	}
+
+	hasElements := false
+	for n := node; n != nil; n = n.NextSibling {
+		if n.Type == html.ElementNode {
+			if strings.ToLower(n.Data) == "html" {
+				return n, nil
+			}
+			hasElements = true
+		}
+	}
+
+	for n := node; n != nil; n = n.NextSibling {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		if body := findSelfOrElement(n, "body"); body != nil {
+			return body, nil
+		}
+	}
+
+	if hasElements {
+		return nil, ErrNotHTML
+	}
+	return nil, ErrNoRoot
+}
+
+// Returns the content of the title element, looking for it outside of head if necessary
 func getTitle(root *html.Node) string {
 	head := findChild(root, "head")
 	title := findChild(head, "title")
+	if title == nil {
+		visit := func(node *html.Node) {
+			// skips svg titles
+			if title == nil && node.Namespace == "" {
+				title = node
+			}
+		}
+		if strings.ToLower(root.Data) == "title" {
+			visit(root)
+		}
+		forEachElement(root, "title", visit)
+	}
 	if title == nil {
 		return ""
 	}
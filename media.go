@@ -0,0 +1,202 @@
+package sandblast
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"net/url"
+	"strings"
+)
+
+// A media element embedded in the extracted text
+type Media struct {
+	Type     string // One of "video", "audio", "post" (social network embeds) or "embed"
+	URL      string // Source of the media
+	Provider string // Name of the service hosting the media (for example "youtube"), host name of URL for unknown services
+	Poster   string // Poster or thumbnail image, if known
+	Title    string // Title of the media, for social network embeds the text of the post
+}
+
+type mediaProvider struct {
+	name  string
+	mtype string
+}
+
+// Known media providers, indexed by domain
+var mediaProviders = map[string]mediaProvider{
+	"youtube.com":          {"youtube", "video"},
+	"youtube-nocookie.com": {"youtube", "video"},
+	"youtu.be":             {"youtube", "video"},
+	"vimeo.com":            {"vimeo", "video"},
+	"dailymotion.com":      {"dailymotion", "video"},
+	"twitch.tv":            {"twitch", "video"},
+	"ted.com":              {"ted", "video"},
+	"soundcloud.com":       {"soundcloud", "audio"},
+	"spotify.com":          {"spotify", "audio"},
+	"bandcamp.com":         {"bandcamp", "audio"},
+	"twitter.com":          {"twitter", "post"},
+	"x.com":                {"twitter", "post"},
+	"instagram.com":        {"instagram", "post"},
+	"facebook.com":         {"facebook", "post"},
+	"tiktok.com":           {"tiktok", "post"},
+}
+
+// Classes used by social networks to mark embedded posts, with the name of the provider
+var socialEmbedClasses = map[string]string{
+	"twitter-tweet":   "twitter",
+	"twitter-video":   "twitter",
+	"instagram-media": "instagram",
+	"tiktok-embed":    "tiktok",
+	"fb-post":         "facebook",
+	"fb-video":        "facebook",
+}
+
+// Returns the provider of an embedded social network post, or the empty string if node isn't one
+func socialEmbedProvider(node *html.Node) string {
+	for _, class := range strings.Fields(strings.ToLower(getAttribute(node, "class"))) {
+		if provider, ok := socialEmbedClasses[class]; ok {
+			return provider
+		}
+	}
+	return ""
+}
+
+// Converts a media element (or an embedded social network post) to a ~media element, returns nil if its source can't be determined
+func simplifyMedia(node *html.Node) *element {
+	m := &Media{Title: strings.TrimSpace(getAttribute(node, "title"))}
+
+	switch tag := strings.ToLower(node.Data); tag {
+	case "video", "audio":
+		m.Type = tag
+		m.URL = getAttribute(node, "src")
+		if m.URL == "" {
+			if source := findElement(node, "source"); source != nil {
+				m.URL = getAttribute(source, "src")
+			}
+		}
+		m.Poster = getAttribute(node, "poster")
+	case "iframe", "embed":
+		m.URL = getAttribute(node, "src")
+		if m.URL == "" || m.URL == "about:blank" {
+			m.URL = getAttribute(node, "data-src")
+		}
+	case "object":
+		m.URL = getAttribute(node, "data")
+		for child := node.FirstChild; m.URL == "" && child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode && strings.ToLower(child.Data) == "param" && strings.ToLower(getAttribute(child, "name")) == "movie" {
+				m.URL = getAttribute(child, "value")
+			}
+		}
+	default:
+		m.Provider = socialEmbedProvider(node)
+		m.Type = "post"
+		m.Title = nodeText(node, nil)
+		for _, name := range []string{"data-href", "data-instgrm-permalink", "cite"} {
+			if m.URL = getAttribute(node, name); m.URL != "" {
+				break
+			}
+		}
+		if m.URL == "" {
+			// the link to the post is the last one in the quote
+			forEachElement(node, "a", func(a *html.Node) {
+				m.URL = getAttribute(a, "href")
+			})
+		}
+	}
+
+	m.URL = strings.TrimSpace(m.URL)
+	if m.URL == "" {
+		return nil
+	}
+
+	provider, known := findMediaProvider(m.URL)
+	if m.Provider == "" {
+		m.Provider = provider.name
+	}
+	if m.Type == "" {
+		if !known {
+			if strings.ToLower(node.Data) == "iframe" {
+				// most iframes with unknown sources are advertisement or widgets
+				return nil
+			}
+			provider.mtype = "embed"
+		}
+		m.Type = provider.mtype
+	}
+	if m.Poster == "" && m.Provider == "youtube" {
+		m.Poster = youtubeThumbnail(m.URL)
+	}
+
+	content := ""
+	if m.Type == "post" {
+		// the text of the post is part of the text of the document
+		content = m.Title
+	}
+	r := newContentElement("~media", content)
+	r.media = m
+	r.sources = []*html.Node{node}
+	return r
+}
+
+// Returns the provider of the media at rawurl and true if it is a known provider
+func findMediaProvider(rawurl string) (mediaProvider, bool) {
+	if strings.HasPrefix(rawurl, "//") {
+		rawurl = "http:" + rawurl
+	}
+	u, err := url.Parse(rawurl)
+	if err != nil || u.Host == "" {
+		return mediaProvider{}, false
+	}
+	host := strings.ToLower(u.Host)
+	if i := strings.Index(host, ":"); i >= 0 {
+		host = host[:i]
+	}
+	for domain := host; domain != ""; {
+		if provider, ok := mediaProviders[domain]; ok {
+			return provider, true
+		}
+		i := strings.Index(domain, ".")
+		if i < 0 {
+			break
+		}
+		domain = domain[i+1:]
+	}
+	return mediaProvider{name: strings.TrimPrefix(host, "www.")}, false
+}
+
+// Returns the thumbnail of a youtube video
+func youtubeThumbnail(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return ""
+	}
+	id := u.Query().Get("v")
+	if id == "" {
+		v := strings.Split(strings.Trim(u.Path, "/"), "/")
+		id = v[len(v)-1]
+	}
+	if id == "" || id == "watch" {
+		return ""
+	}
+	return fmt.Sprintf("https://img.youtube.com/vi/%s/hqdefault.jpg", id)
+}
+
+// Calls fn on every descendant of node with the specified tag name
+func forEachElement(node *html.Node, name string, fn func(*html.Node)) {
+	walkNodes(node, func(child *html.Node) bool {
+		if child.Type == html.ElementNode && strings.ToLower(child.Data) == name {
+			fn(child)
+		}
+		return true
+	})
+}
+
+// Returns the media of a cleaned tree
+func collectMedia(cleaned *element) []*Media {
+	r := []*Media{}
+	walkElements(cleaned, func(e *element) {
+		if e.media != nil {
+			r = append(r, e.media)
+		}
+	})
+	return r
+}
@@ -0,0 +1,56 @@
+package sandblast
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+const mediaTestDoc = `<html><body>
+<p>This is the first paragraph of the article, long enough to be kept as text.</p>
+<iframe src="https://www.youtube.com/embed/dQw4w9WgXcQ" title="A video"></iframe>
+<p>This is the second paragraph of the article, long enough to be kept as text.</p>
+<video poster="/poster.jpg"><source src="/clip.mp4" type="video/mp4"></video>
+<p>This is the third paragraph of the article, long enough to be kept as text.</p>
+<blockquote class="twitter-tweet"><p>Hello world</p>&mdash; Someone (@someone) <a href="https://twitter.com/someone/status/123">March 1, 2020</a></blockquote>
+<p>This is the fourth paragraph of the article, long enough to be kept as text.</p>
+<iframe src="https://ads.example.com/banner"></iframe>
+</body></html>`
+
+func TestMedia(t *testing.T) {
+	node, err := html.Parse(strings.NewReader(mediaTestDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ExtractResult(node, MarkMedia)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := []string{}
+	for _, m := range r.Media {
+		out = append(out, fmt.Sprintf("%s %s %s %s <%s>", m.Type, m.Provider, m.URL, m.Poster, m.Title))
+	}
+	tgt := []string{
+		"video youtube https://www.youtube.com/embed/dQw4w9WgXcQ https://img.youtube.com/vi/dQw4w9WgXcQ/hqdefault.jpg <A video>",
+		"video  /clip.mp4 /poster.jpg <>",
+		"post twitter https://twitter.com/someone/status/123  <Hello world — Someone (@someone) March 1, 2020>",
+	}
+	if strings.Join(out, "\n") != strings.Join(tgt, "\n") {
+		t.Errorf("Wrong media\n\tgot:\n%s\n\texpected:\n%s\n", strings.Join(out, "\n"), strings.Join(tgt, "\n"))
+	}
+
+	if strings.Index(r.Text, "[video: /clip.mp4]") < 0 || strings.Index(r.Text, "Hello world — Someone (@someone) March 1, 2020 [post: https://twitter.com/someone/status/123]") < 0 {
+		t.Errorf("Missing placeholders in text:\n%s\n", r.Text)
+	}
+
+	// without placeholders media without text don't add empty lines, the text of posts is kept
+	r, err = ExtractResult(node, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Index(strings.TrimSpace(r.Text), "\n\n") >= 0 || strings.Index(r.Text, "Hello world — Someone (@someone) March 1, 2020") < 0 {
+		t.Errorf("Wrong text without placeholders:\n%q\n", r.Text)
+	}
+}
@@ -0,0 +1,364 @@
+package sandblast
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang.org/x/net/html"
+	"io"
+	"math"
+	"strings"
+)
+
+// Names of the features of a block used by Model, see blockFeatures
+var modelFeatures = []string{
+	"length", "words", "linkDensity", "stopwordDensity", "commas", "classHints", "position", "center",
+	"header", "caption", "figure", "textblock",
+	"inP", "inLi", "inTd", "inBoilerplateTag", "inContentTag", "depth",
+	"prevLength", "nextLength", "prevLinkDensity", "nextLinkDensity",
+}
+
+// A logistic regression classifier of blocks, trained with a Trainer and saved as JSON.
+// A model is an Extractor: blocks whose probability of being content is at least Threshold are kept.
+type Model struct {
+	Features  []string  `json:"features"` // names of the features, in the order of Weights
+	Weights   []float64 `json:"weights"`
+	Bias      float64   `json:"bias"`
+	Threshold float64   `json:"threshold"`
+}
+
+// Reads a model saved with Model.Save
+func LoadModel(r io.Reader) (*Model, error) {
+	m := &Model{}
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Returns an error if the features or the weights of the model are invalid.
+// The extraction functions return this error instead of extracting with an invalid model.
+func (m *Model) Validate() error {
+	_, err := m.featureIndex()
+	return err
+}
+
+// Returns the index of each feature of the model in modelFeatures
+func (m *Model) featureIndex() ([]int, error) {
+	if len(m.Features) != len(m.Weights) {
+		return nil, fmt.Errorf("Wrong number of weights in model: %d weights for %d features", len(m.Weights), len(m.Features))
+	}
+	index := make([]int, len(m.Features))
+	for i, name := range m.Features {
+		index[i] = -1
+		for j := range modelFeatures {
+			if modelFeatures[j] == name {
+				index[i] = j
+				break
+			}
+		}
+		if index[i] < 0 {
+			return nil, fmt.Errorf("Unknown feature in model: %s", name)
+		}
+	}
+	return index, nil
+}
+
+// Writes the model as JSON
+func (m *Model) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(m)
+}
+
+func (m *Model) Name() string { return "model" }
+
+// Returns the probability that a block with the specified features is content, index is returned by featureIndex
+func (m *Model) probability(index []int, features []float64) float64 {
+	z := m.Bias
+	for i, j := range index {
+		z += m.Weights[i] * features[j]
+	}
+	return 1 / (1 + math.Exp(-z))
+}
+
+// Doesn't decide anything if the model is invalid (see Validate)
+func (m *Model) Classify(s *BlockSet) {
+	index, err := m.featureIndex()
+	if err != nil {
+		return
+	}
+	features := blockFeatures(s.blocks, s.profile)
+	for i, block := range s.parent.childs {
+		if s.Decided(i) || block.span > 0 {
+			continue
+		}
+		if p := m.probability(index, features[i]); p >= m.Threshold {
+			s.Keep(i, "model probability %.2f", p)
+		} else {
+			s.Drop(i, "model probability %.2f", p)
+		}
+	}
+}
+
+// Returns the features of each block, nil for nil blocks
+func blockFeatures(blocks []*element, profile *languageProfile) [][]float64 {
+	n := 0
+	for _, block := range blocks {
+		if block != nil {
+			n++
+		}
+	}
+
+	type basic struct {
+		length, linkDensity float64
+	}
+	basics := make([]basic, len(blocks))
+	r := make([][]float64, len(blocks))
+	pos := 0
+	for i, block := range blocks {
+		if block == nil {
+			continue
+		}
+		text := blockText(block)
+		words := splitWords(text)
+		stopwords := 0
+		for _, w := range words {
+			if profile.isStopword(w) {
+				stopwords++
+			}
+		}
+		f := map[string]float64{}
+		length := float64(textLength(text))
+		f["length"] = math.Log1p(length) / 7
+		f["words"] = math.Log1p(float64(wordCount(text))) / 5
+		f["linkDensity"] = float64(block.linkPart)
+		if len(words) > 0 {
+			f["stopwordDensity"] = float64(stopwords) / float64(len(words))
+			f["commas"] = math.Min(1, float64(strings.Count(text, ",")+strings.Count(text, "，")+strings.Count(text, "、"))/float64(len(words))*5)
+		}
+		f["classHints"] = classHintsVote(block)
+		if n > 1 {
+			p := float64(pos) / float64(n-1)
+			f["position"] = p
+			f["center"] = 2 * math.Min(p, 1-p)
+		}
+		pos++
+
+		switch block.tag {
+		case "~header":
+			f["header"] = 1
+		case "~caption":
+			f["caption"] = 1
+		case "~figure", "~media":
+			f["figure"] = 1
+		case "~textblock":
+			f["textblock"] = 1
+		}
+
+		if sources := block.allSources(); len(sources) > 0 {
+			depth := 0
+			for node := sources[0].Parent; node != nil; node = node.Parent {
+				if node.Type != html.ElementNode {
+					continue
+				}
+				depth++
+				tag := strings.ToLower(node.Data)
+				switch {
+				case tag == "p":
+					f["inP"] = 1
+				case tag == "li":
+					f["inLi"] = 1
+				case tag == "td":
+					f["inTd"] = 1
+				case boilerplateTags[tag]:
+					f["inBoilerplateTag"] = 1
+				case contentTags[tag]:
+					f["inContentTag"] = 1
+				}
+			}
+			f["depth"] = math.Min(1, float64(depth)/20)
+		}
+
+		basics[i] = basic{f["length"], f["linkDensity"]}
+		r[i] = make([]float64, len(modelFeatures))
+		for j, name := range modelFeatures {
+			r[i][j] = f[name]
+		}
+	}
+
+	// features of the closest blocks before and after each block
+	index := func(name string) int {
+		for j := range modelFeatures {
+			if modelFeatures[j] == name {
+				return j
+			}
+		}
+		panic("unknown feature " + name)
+	}
+	prevLength, nextLength, prevLinkDensity, nextLinkDensity := index("prevLength"), index("nextLength"), index("prevLinkDensity"), index("nextLinkDensity")
+	var prev *basic
+	for i := range blocks {
+		if r[i] == nil {
+			continue
+		}
+		if prev != nil {
+			r[i][prevLength], r[i][prevLinkDensity] = prev.length, prev.linkDensity
+		}
+		prev = &basics[i]
+	}
+	var next *basic
+	for i := len(blocks) - 1; i >= 0; i-- {
+		if r[i] == nil {
+			continue
+		}
+		if next != nil {
+			r[i][nextLength], r[i][nextLinkDensity] = next.length, next.linkDensity
+		}
+		next = &basics[i]
+	}
+	return r
+}
+
+// Collects training examples from documents paired with the text that should be extracted from them, and fits a Model
+type Trainer struct {
+	features [][]float64
+	labels   []bool
+}
+
+// Adds the blocks of a document to the training examples, a block is labeled as content if most of its words are in target
+func (t *Trainer) Add(node *html.Node, target string) error {
+	root, err := findRoot(node)
+	if err != nil {
+		return err
+	}
+	roots := []*html.Node{root}
+	simplified, _ := simplify(roots, 0, nil)
+	if simplified == nil {
+		return ErrEmptyContent
+	}
+	lang, _ := findLanguage(roots, simplified)
+	flattened := flatten(simplified, DefaultThresholds.withDefaults(), nil)
+	blocks := flattened.childs
+	if blocks == nil {
+		blocks = []*element{flattened}
+	}
+
+	features := blockFeatures(blocks, profileFor(lang))
+	labels := alignBlocks(blocks, target)
+	for i, block := range blocks {
+		if block == nil || block.tag == "~linklist" || block.tag == "~linkblob" {
+			// always dropped
+			continue
+		}
+		t.features = append(t.features, features[i])
+		t.labels = append(t.labels, labels[i])
+	}
+	return nil
+}
+
+// Returns true for the blocks whose words are mostly found in target, every word of target is matched by one block at most
+func alignBlocks(blocks []*element, target string) []bool {
+	words := map[string]int{}
+	for _, w := range splitWords(strings.ToLower(target)) {
+		words[w]++
+	}
+	r := make([]bool, len(blocks))
+	for i, block := range blocks {
+		if block == nil {
+			continue
+		}
+		bw := splitWords(strings.ToLower(blockText(block)))
+		if len(bw) == 0 {
+			continue
+		}
+		found := 0
+		for _, w := range bw {
+			if words[w] > 0 {
+				found++
+			}
+		}
+		if found*2 > len(bw) {
+			r[i] = true
+			for _, w := range bw {
+				if words[w] > 0 {
+					words[w]--
+				}
+			}
+		}
+	}
+	return r
+}
+
+const (
+	_TRAIN_EPOCHS = 1000
+	_TRAIN_RATE   = 0.5
+	_TRAIN_L2     = 0.001
+)
+
+// Returns the number of training examples and how many of them are content
+func (t *Trainer) Len() (examples, content int) {
+	for _, label := range t.labels {
+		if label {
+			content++
+		}
+	}
+	return len(t.labels), content
+}
+
+// Fits a logistic regression model to the training examples with batch gradient descent,
+// content and boilerplate examples are weighted so that the two classes count the same
+func (t *Trainer) Train() *Model {
+	m := &Model{Features: append([]string(nil), modelFeatures...), Weights: make([]float64, len(modelFeatures)), Threshold: 0.5}
+	index, _ := m.featureIndex()
+	examples, content := t.Len()
+	if examples == 0 {
+		return m
+	}
+	weight := [2]float64{1, 1} // boilerplate, content
+	if content > 0 && content < examples {
+		weight[0] = float64(examples) / float64(2*(examples-content))
+		weight[1] = float64(examples) / float64(2*content)
+	}
+
+	grad := make([]float64, len(m.Weights))
+	for epoch := 0; epoch < _TRAIN_EPOCHS; epoch++ {
+		for j := range grad {
+			grad[j] = 0
+		}
+		gradBias := 0.0
+		for i, features := range t.features {
+			y, w := 0.0, weight[0]
+			if t.labels[i] {
+				y, w = 1, weight[1]
+			}
+			d := w * (m.probability(index, features) - y)
+			for j := range grad {
+				grad[j] += d * features[j]
+			}
+			gradBias += d
+		}
+		for j := range m.Weights {
+			m.Weights[j] -= _TRAIN_RATE * (grad[j]/float64(examples) + _TRAIN_L2*m.Weights[j])
+		}
+		m.Bias -= _TRAIN_RATE * gradBias / float64(examples)
+	}
+	return m
+}
+
+// Returns the fraction of the training examples the model classifies correctly
+func (t *Trainer) Accuracy(m *Model) float64 {
+	index, err := m.featureIndex()
+	if len(t.labels) == 0 || err != nil {
+		return 0
+	}
+	correct := 0
+	for i, features := range t.features {
+		if (m.probability(index, features) >= m.Threshold) == t.labels[i] {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(t.labels))
+}
@@ -0,0 +1,74 @@
+package sandblast
+
+import (
+	"bytes"
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestModel(t *testing.T) {
+	const target = `The new bridge
+The city council met on Tuesday evening to discuss the plan for the new bridge, which has been delayed for more than two years because of a long dispute between the city and the company that won the contract to build it.
+According to the mayor, the work will start again in the spring and the bridge should be open to traffic by the end of next year, if the weather is not too bad and there are no other problems with the company.
+The opposition, however, said that the new schedule is not realistic, and that the city should look for another company to finish the work, since the costs have already doubled.`
+
+	var tr Trainer
+	node, err := html.Parse(strings.NewReader(extractorTestDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Add(node, target); err != nil {
+		t.Fatal(err)
+	}
+	if examples, content := tr.Len(); examples != 6 || content != 4 {
+		t.Errorf("Wrong training examples: %d examples, %d content", examples, content)
+	}
+	m := tr.Train()
+	if acc := tr.Accuracy(m); acc != 1 {
+		t.Errorf("Wrong accuracy on the training examples: %g", acc)
+	}
+
+	var buf bytes.Buffer
+	if err := m.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	m2, err := LoadModel(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node, err = html.Parse(strings.NewReader(extractorTestDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ExtractWithOptions(node, &Options{Extractor: m2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, tgt := string(collapseWhitespace([]rune(r.Text))), string(collapseWhitespace([]rune(target))); strings.TrimSpace(out) != tgt {
+		t.Errorf("Error extracting with the model\n\tgot <%s>\n\texpected <%s>\n", out, tgt)
+	}
+
+	if _, err := LoadModel(strings.NewReader(`{"features": ["unknown"], "weights": [1]}`)); err == nil {
+		t.Errorf("No error loading a model with an unknown feature")
+	}
+	if _, err := LoadModel(strings.NewReader(`{"features": ["length"], "weights": []}`)); err == nil {
+		t.Errorf("No error loading a model with missing weights")
+	}
+
+	invalid := &Model{Features: []string{"length", "words"}, Weights: []float64{1}}
+	if err := invalid.Validate(); err == nil {
+		t.Errorf("No error validating a model with missing weights")
+	}
+	node, err = html.Parse(strings.NewReader(extractorTestDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ExtractWithOptions(node, &Options{Extractor: invalid}); err == nil || err.Error() != invalid.Validate().Error() {
+		t.Errorf("Wrong error extracting with an invalid model: %v", err)
+	}
+	if _, err := ExtractNode(findElement(node, "body"), &Options{Extractor: invalid}); err == nil {
+		t.Errorf("No error extracting a node with an invalid model")
+	}
+}
@@ -0,0 +1,77 @@
+package sandblast
+
+import (
+	"strings"
+)
+
+// A section of the extracted text
+type Section struct {
+	Level    int        // Level of the heading introducing the section (1 to 6), 0 for the top of the document
+	Heading  string     // Text of the heading introducing the section
+	ID       string     // Anchor of the heading, see TOCEntry.ID
+	Text     string     // Text of the section, excluding its subsections
+	Sections []*Section // Subsections
+}
+
+// Builds the outline of a cleaned tree: every ~header starts a new section nested inside the last section with a lower level.
+// ids are the ids of the headings, see headingIds.
+func buildOutline(cleaned *element, ids map[*element]string) *Section {
+	root := &Section{}
+	if cleaned == nil {
+		return root
+	}
+
+	blocks := cleaned.childs
+	if blocks == nil {
+		blocks = []*element{cleaned}
+	}
+
+	stack := []*Section{root}
+	for _, block := range blocks {
+		if block == nil {
+			continue
+		}
+		text := blockText(block)
+		if text == "" {
+			continue
+		}
+
+		if block.tag == "~header" {
+			level := block.headingLevel()
+			for stack[len(stack)-1].Level >= level {
+				stack = stack[:len(stack)-1]
+			}
+			s := &Section{Level: level, Heading: text, ID: ids[block]}
+			top := stack[len(stack)-1]
+			top.Sections = append(top.Sections, s)
+			stack = append(stack, s)
+			continue
+		}
+
+		top := stack[len(stack)-1]
+		if top.Text != "" {
+			top.Text += "\n"
+		}
+		top.Text += text
+	}
+
+	return root
+}
+
+// Returns the text contained in e without link markers
+func blockText(e *element) string {
+	if e.childs == nil {
+		var lctxt linkContext
+		return strings.TrimSpace(string(collapseWhitespace([]rune(lctxt.convertLinks(e.content, false)))))
+	}
+	v := make([]string, 0, len(e.childs))
+	for _, child := range e.childs {
+		if child == nil {
+			continue
+		}
+		if t := blockText(child); t != "" {
+			v = append(v, t)
+		}
+	}
+	return strings.Join(v, "\n")
+}
@@ -0,0 +1,111 @@
+package sandblast
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+const outlineTestDoc = `<html><head><title>Test</title></head><body>
+<p>This is the introduction of the document, long enough to be kept as text.</p>
+<h1>First chapter</h1>
+<p>This is the first paragraph of the first chapter, long enough to be kept.</p>
+<h2>A section</h2>
+<p>This is the first paragraph of the section, long enough to be kept as text.</p>
+<h4>A <em>deep</em> heading</h4>
+<p>This is the paragraph under the deep heading, long enough to be kept as text.</p>
+<h1>Second chapter</h1>
+<p>This is the first paragraph of the second chapter, long enough to be kept.</p>
+</body></html>`
+
+func TestOutline(t *testing.T) {
+	node, err := html.Parse(strings.NewReader(outlineTestDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ExtractResult(node, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out []string
+	var walk func(s *Section, depth int)
+	walk = func(s *Section, depth int) {
+		out = append(out, makeIndent(depth)+strings.TrimSpace(strings.Repeat("#", s.Level)+s.Heading+" ("+strings.SplitN(s.Text, ",", 2)[0]+")"))
+		for _, sub := range s.Sections {
+			walk(sub, depth+1)
+		}
+	}
+	walk(r.Outline, 0)
+
+	tgt := []string{
+		"(This is the introduction of the document)",
+		"   #First chapter (This is the first paragraph of the first chapter)",
+		"      ##A section (This is the first paragraph of the section)",
+		"         ####A deep heading (This is the paragraph under the deep heading)",
+		"   #Second chapter (This is the first paragraph of the second chapter)",
+	}
+
+	if strings.Join(out, "\n") != strings.Join(tgt, "\n") {
+		t.Errorf("Wrong outline\n\tgot:\n%s\n\texpected:\n%s\n", strings.Join(out, "\n"), strings.Join(tgt, "\n"))
+	}
+}
+
+func TestTOC(t *testing.T) {
+	const doc = `<html><body>
+<h2 id="intro">Introduction</h2>
+<p>This is the introduction of the document, long enough to be kept as text.</p>
+<h2><span class="headline" id="Some_history">History</span></h2>
+<p>This is the paragraph about history, long enough to be kept as text too.</p>
+<h3>Notes</h3>
+<p>This is the first paragraph of notes, long enough to be kept as text too.</p>
+<h2>Notes</h2>
+<p>This is the second paragraph of notes, long enough to be kept as text too.</p>
+</body></html>`
+
+	node, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ExtractResult(node, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out []string
+	var walk func(entries []*TOCEntry)
+	walk = func(entries []*TOCEntry) {
+		for _, e := range entries {
+			out = append(out, fmt.Sprintf("%d %s #%s", e.Level, e.Title, e.ID))
+			walk(e.Entries)
+		}
+	}
+	walk(r.TOC)
+
+	tgt := "2 Introduction #intro\n2 History #Some_history\n3 Notes #notes\n2 Notes #notes-2"
+	if strings.Join(out, "\n") != tgt {
+		t.Errorf("Wrong table of contents\n\tgot:\n%s\n\texpected:\n%s\n", strings.Join(out, "\n"), tgt)
+	}
+
+	// every entry points to a heading paragraph
+	headings := map[string]*Paragraph{}
+	for _, p := range r.Paragraphs {
+		if p.ID != "" {
+			headings[p.ID] = p
+		}
+	}
+	var check func(entries []*TOCEntry)
+	check = func(entries []*TOCEntry) {
+		for _, e := range entries {
+			if p := headings[e.ID]; p == nil || p.Text != e.Title || p.Heading != e.Level {
+				t.Errorf("TOC entry #%s doesn't point to its heading", e.ID)
+			}
+			check(e.Entries)
+		}
+	}
+	check(r.TOC)
+	if len(headings) != 4 {
+		t.Errorf("Wrong number of paragraphs with an id: %d", len(headings))
+	}
+}
@@ -0,0 +1,309 @@
+package sandblast
+
+import (
+	"encoding/json"
+	"golang.org/x/net/html"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Type of a page, see Result.PageType
+type PageType string
+
+const (
+	PageArticle       PageType = "article"       // a single article: news, blog post, essay...
+	PageListing       PageType = "listing"       // an index of other pages: home page, category, search results...
+	PageForum         PageType = "forum"         // a discussion made of several posts by different authors
+	PageProduct       PageType = "product"       // a product that can be bought
+	PageDocumentation PageType = "documentation" // technical documentation, with code samples
+	PageError         PageType = "error"         // an error page: not found, forbidden...
+	PageOther         PageType = "other"         // none of the above
+)
+
+// Signals computed on the blocks of a flattened tree, before cleaning it
+type pageSignals struct {
+	blocks, okBlocks, headers, codeBlocks int
+	linkLists, linkBlobs                  int
+	okChars, totalChars, linkChars        float64
+	bestCluster                           float64 // ok text (in characters) of the largest cluster of ok blocks, see confidence
+	posts                                 int     // number of distinct elements marked as posts or comments that contain ok text
+	bestPost, postChars                   float64 // ok text of the largest of these elements and of all of them
+	price, cart                           bool    // the text contains a price or a button to buy something
+}
+
+// Class and id hints of the elements containing the posts of a forum or the comments of a page
+var postHints = []string{"post", "comment", "reply", "message", "thread", "topic"}
+
+var (
+	priceRegexp = regexp.MustCompile(`[$€£¥₹]\s?\d|\d[\d.,]*\s?(?:€|£|EUR|USD|GBP|CHF)`)
+	cartPhrases = []string{"add to cart", "add to basket", "add to bag", "buy now", "in den warenkorb", "ajouter au panier", "añadir al carrito", "aggiungi al carrello"}
+)
+
+// Collects the signals of the blocks of a flattened tree
+func collectSignals(flattened *element, profile *languageProfile) *pageSignals {
+	s := &pageSignals{}
+	if flattened == nil {
+		return s
+	}
+	blocks := flattened.childs
+	if blocks == nil {
+		blocks = []*element{flattened}
+	}
+
+	var cluster float64
+	gap := 0
+	posts := map[*html.Node]float64{}
+	for _, block := range blocks {
+		if block == nil {
+			continue
+		}
+		s.blocks++
+		text := blockText(block)
+		length := float64(textLength(text))
+		s.totalChars += length
+		s.linkChars += length * float64(block.linkPart)
+		switch block.tag {
+		case "~header":
+			s.headers++
+		case "~linklist":
+			s.linkLists++
+		case "~linkblob":
+			s.linkBlobs++
+		}
+		lower := strings.ToLower(text)
+		s.price = s.price || priceRegexp.MatchString(text)
+		s.cart = s.cart || containsAny(lower, cartPhrases)
+		sources := block.allSources()
+		if isCodeBlock(sources) {
+			s.codeBlocks++
+		}
+
+		if !profile.okText(block) {
+			gap++
+			if gap > _CONFIDENCE_GAP {
+				cluster = 0
+			}
+			continue
+		}
+		gap = 0
+		s.okBlocks++
+		s.okChars += length
+		cluster += length
+		s.bestCluster = math.Max(s.bestCluster, cluster)
+		if len(sources) > 0 {
+			if post := postNode(sources[0]); post != nil {
+				posts[post] += length
+			}
+		}
+	}
+
+	s.posts = len(posts)
+	for _, length := range posts {
+		s.postChars += length
+		s.bestPost = math.Max(s.bestPost, length)
+	}
+	return s
+}
+
+// Returns true if the block built from sources is preformatted text or code, always false for trees without a document
+func isCodeBlock(sources []*html.Node) bool {
+	if len(sources) == 0 {
+		return false
+	}
+	for node := sources[0]; node != nil; node = node.Parent {
+		if node.Type != html.ElementNode {
+			continue
+		}
+		switch strings.ToLower(node.Data) {
+		case "pre", "code":
+			return true
+		case "p", "li", "div", "td", "body":
+			return false
+		}
+	}
+	return false
+}
+
+// Returns the closest element containing node whose class or id marks it as a post or a comment
+func postNode(node *html.Node) *html.Node {
+	for ; node != nil; node = node.Parent {
+		if node.Type != html.ElementNode {
+			continue
+		}
+		hints := strings.ToLower(getAttribute(node, "class") + " " + getAttribute(node, "id"))
+		if containsAny(hints, postHints) {
+			return node
+		}
+	}
+	return nil
+}
+
+func containsAny(s string, substrs []string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// Page types of schema.org types, in order of priority when a page declares several of them
+var schemaPageTypes = []struct {
+	pageType PageType
+	types    []string
+}{
+	{PageProduct, []string{"Product", "ProductGroup", "ProductModel", "IndividualProduct"}},
+	{PageForum, []string{"DiscussionForumPosting", "QAPage"}},
+	{PageDocumentation, []string{"TechArticle", "APIReference"}},
+	{PageArticle, []string{"Article", "NewsArticle", "BlogPosting", "Report", "ScholarlyArticle", "LiveBlogPosting",
+		"OpinionNewsArticle", "ReportageNewsArticle", "AnalysisNewsArticle", "BackgroundNewsArticle", "ReviewNewsArticle"}},
+	{PageListing, []string{"ItemList", "CollectionPage", "SearchResultsPage", "Blog"}},
+}
+
+// Titles and headings of error pages
+var errorRegexp = regexp.MustCompile(`(?i)\b(?:40[34]|410|50[023])\b|not found|page not found|forbidden|access denied|does ?n[o']t exist|no longer (?:exists|available)|unavailable`)
+
+const (
+	_ERROR_MAX_LENGTH   = 1000 // error pages have less ok text (in characters)
+	_LISTING_LINK_RATIO = 0.4  // fraction of the text in links of listings
+)
+
+// Returns the type of the page and where it was found: "ld+json" (JSON-LD structured data), "microdata" (itemtype attributes),
+// "og:type" (the og:type meta property) or "text" (the blocks of the document)
+func findPageType(roots []*html.Node, title string, signals *pageSignals, confidence float64) (PageType, string) {
+	if t, source := structuredPageType(roots); t != "" {
+		return t, source
+	}
+	if signals == nil {
+		signals = &pageSignals{}
+	}
+
+	h1 := ""
+	for _, root := range roots {
+		if node := findSelfOrElement(root, "h1"); node != nil {
+			h1 = nodeText(node, nil)
+			break
+		}
+	}
+	switch {
+	case signals.okChars < _ERROR_MAX_LENGTH && (errorRegexp.MatchString(title) || errorRegexp.MatchString(h1)):
+		return PageError, "text"
+	case signals.posts >= 3 && signals.postChars*2 >= signals.okChars && signals.bestPost*2 < signals.postChars:
+		return PageForum, "text"
+	case signals.price && (signals.cart || hasCartButton(roots)):
+		return PageProduct, "text"
+	case signals.codeBlocks >= 2 && signals.headers >= 3:
+		return PageDocumentation, "text"
+	case confidence >= 0.5:
+		return PageArticle, "text"
+	case signals.linkLists+signals.linkBlobs >= 3 || (signals.totalChars > 0 && signals.linkChars/signals.totalChars >= _LISTING_LINK_RATIO):
+		return PageListing, "text"
+	case confidence >= 0.2:
+		return PageArticle, "text"
+	}
+	return PageOther, ""
+}
+
+// Returns the page type declared by the schema.org types of the JSON-LD structured data, the microdata or the og:type meta property
+func structuredPageType(roots []*html.Node) (PageType, string) {
+	var ld, micro []string
+	og := ""
+	for _, root := range roots {
+		forEachElement(root, "script", func(script *html.Node) {
+			if !strings.EqualFold(strings.TrimSpace(getAttribute(script, "type")), "application/ld+json") || script.FirstChild == nil {
+				return
+			}
+			var data interface{}
+			if json.Unmarshal([]byte(script.FirstChild.Data), &data) == nil {
+				ld = appendSchemaTypes(ld, data)
+			}
+		})
+		walkNodes(root, func(node *html.Node) bool {
+			if node.Type == html.ElementNode && hasAttribute(node, "itemscope") && !hasAttribute(node, "itemprop") {
+				for _, t := range strings.Fields(getAttribute(node, "itemtype")) {
+					micro = append(micro, t[strings.LastIndexAny(t, "/#")+1:])
+				}
+			}
+			return true
+		})
+		if og == "" {
+			og = strings.ToLower(getMeta(root, "og:type"))
+		}
+	}
+
+	if t := schemaPageType(ld); t != "" {
+		return t, "ld+json"
+	}
+	if t := schemaPageType(micro); t != "" {
+		return t, "microdata"
+	}
+	switch og {
+	case "article":
+		return PageArticle, "og:type"
+	case "product", "og:product", "product.item", "product.group":
+		return PageProduct, "og:type"
+	}
+	return "", ""
+}
+
+// Appends the @type of the top level items of JSON-LD data, including the items of @graph and the main entities
+func appendSchemaTypes(r []string, data interface{}) []string {
+	switch v := data.(type) {
+	case []interface{}:
+		for _, item := range v {
+			r = appendSchemaTypes(r, item)
+		}
+	case map[string]interface{}:
+		switch t := v["@type"].(type) {
+		case string:
+			r = append(r, t)
+		case []interface{}:
+			for _, item := range t {
+				if s, ok := item.(string); ok {
+					r = append(r, s)
+				}
+			}
+		}
+		r = appendSchemaTypes(r, v["@graph"])
+		r = appendSchemaTypes(r, v["mainEntity"])
+	}
+	return r
+}
+
+// Returns the page type of the schema.org type with the highest priority in types
+func schemaPageType(types []string) PageType {
+	for _, st := range schemaPageTypes {
+		for _, t := range types {
+			t = t[strings.LastIndexAny(t, "/:")+1:]
+			for _, name := range st.types {
+				if t == name {
+					return st.pageType
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// Returns true if the document has a button or a form to add something to a cart
+func hasCartButton(roots []*html.Node) bool {
+	found := false
+	for _, root := range roots {
+		walkNodes(root, func(node *html.Node) bool {
+			if found || node.Type != html.ElementNode {
+				return !found
+			}
+			switch strings.ToLower(node.Data) {
+			case "button":
+				found = containsAny(strings.ToLower(nodeText(node, nil)), cartPhrases)
+			case "input":
+				found = containsAny(strings.ToLower(getAttribute(node, "value")), cartPhrases)
+			case "form":
+				found = containsAny(strings.ToLower(getAttribute(node, "action")), []string{"cart", "basket"})
+			}
+			return !found
+		})
+	}
+	return found
+}
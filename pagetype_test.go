@@ -0,0 +1,58 @@
+package sandblast
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestPageType(t *testing.T) {
+	tf := func(name, doc string, target PageType, targetSource string) {
+		node, err := html.Parse(strings.NewReader(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, _ := ExtractResult(node, 0)
+		if r.PageType != target || r.PageTypeSource != targetSource {
+			t.Errorf("Error finding page type of %s\n\tgot <%s> (%s)\n\texpected <%s> (%s)\n", name, r.PageType, r.PageTypeSource, target, targetSource)
+		}
+	}
+
+	paragraphs := strings.Repeat("<p>"+confidenceParagraph+"</p>", 8)
+	tf("article", "<html><body><h1>The new bridge</h1>"+paragraphs+"</body></html>", PageArticle, "text")
+	tf("ld+json", `<html><head><script type="application/ld+json">{"@context": "https://schema.org", "@graph": [{"@type": "WebPage"}, {"@type": ["Product"]}]}</script></head><body><p>Login</p></body></html>`,
+		PageProduct, "ld+json")
+	tf("ld+json priority", `<html><head><script type="application/ld+json">[{"@type": "ItemList"}, {"@type": "NewsArticle"}]</script></head><body><p>Login</p></body></html>`,
+		PageArticle, "ld+json")
+	tf("microdata", `<html><body><div itemscope itemtype="https://schema.org/DiscussionForumPosting"><p>Hello</p></div></body></html>`, PageForum, "microdata")
+	tf("og:type", `<html><head><meta property="og:type" content="article"></head><body><p>Login</p></body></html>`, PageArticle, "og:type")
+
+	listing := "<html><body>"
+	for i := 0; i < 4; i++ {
+		listing += "<ul>" + strings.Repeat("<li><a href='/story'>A story about the new bridge</a></li>", 6) + "</ul>"
+	}
+	listing += "</body></html>"
+	tf("listing", listing, PageListing, "text")
+
+	tf("error", "<html><head><title>404 Not Found</title></head><body><h1>Not Found</h1><p>The requested URL was not found on this server.</p></body></html>", PageError, "text")
+	tf("long article about errors", "<html><head><title>Not found: the lost city</title></head><body>"+paragraphs+"</body></html>", PageArticle, "text")
+
+	forum := "<html><body><h1>Which bridge?</h1>"
+	for i := 0; i < 4; i++ {
+		forum += "<div class='post'><div class='author'>user</div><p>" + confidenceParagraph + "</p></div>"
+	}
+	forum += "</body></html>"
+	tf("forum", forum, PageForum, "text")
+	tf("article with a comment", "<html><body><div class='post-body'>"+paragraphs+"</div><div class='comment'><p>"+confidenceParagraph+"</p></div></body></html>", PageArticle, "text")
+
+	tf("product", "<html><body><h1>Blue kettle</h1><p>A kettle that boils water in two minutes, with a blue handle.</p><p>Price: $24.99</p><form><button>Add to cart</button></form></body></html>", PageProduct, "text")
+
+	doc := "<html><body>"
+	for i := 0; i < 3; i++ {
+		doc += "<h2>Function</h2><p>" + confidenceParagraph + "</p><pre>x := f(1, 2)\nfmt.Println(x)</pre>"
+	}
+	doc += "</body></html>"
+	tf("documentation", doc, PageDocumentation, "text")
+
+	tf("empty page", "<html><body><p>Login</p></body></html>", PageOther, "")
+}
@@ -16,9 +16,17 @@ import (
 )
 
 func usage() {
-	fmt.Fprintf(os.Stderr, "./qa run <dataset.zip>\n")
+	fmt.Fprintf(os.Stderr, "./qa run <dataset.zip> [extractor]\n")
 	fmt.Fprintf(os.Stderr, "./qa rebuild <dataset.zip> <out.zip>\n")
-	fmt.Fprintf(os.Stderr, "./qa one <dataset.zip> <testname>\n")
+	fmt.Fprintf(os.Stderr, "./qa one <dataset.zip> <testname> [extractor]\n")
+	fmt.Fprintf(os.Stderr, "./qa eval <dataset.zip> [model.json]\n")
+	fmt.Fprintf(os.Stderr, "./qa train <dataset.zip> <model.json>\n")
+	fmt.Fprintf(os.Stderr, "./qa tune <dataset.zip> [extractor] [language]\n")
+	fmt.Fprintf(os.Stderr, "extractors:")
+	for _, ex := range sandblast.Extractors() {
+		fmt.Fprintf(os.Stderr, " %s", ex.Name())
+	}
+	fmt.Fprintf(os.Stderr, " or a model file (.json)\n")
 	os.Exit(1)
 }
 
@@ -110,7 +118,7 @@ func openDataset(datapath string) *Dataset {
 	return &Dataset{index: index, datazip: dataset, tests: tests}
 }
 
-func qarun(datapath string) {
+func qarun(datapath string, ex sandblast.Extractor) {
 	dataset := openDataset(datapath)
 	defer dataset.Close()
 
@@ -119,7 +127,7 @@ func qarun(datapath string) {
 	count := 0
 	for _, test := range dataset.tests {
 		fmt.Printf("Processing %s\n", test.name)
-		if !qaruntest(test, false) {
+		if !qaruntest(test, ex, false) {
 			count++
 		}
 		if count > 10 {
@@ -130,7 +138,7 @@ func qarun(datapath string) {
 	fmt.Printf("All ok\n")
 }
 
-func qaone(datapath string, name string) {
+func qaone(datapath string, name string, ex sandblast.Extractor) {
 	dataset := openDataset(datapath)
 	defer dataset.Close()
 
@@ -138,13 +146,14 @@ func qaone(datapath string, name string) {
 
 	for _, test := range dataset.tests {
 		if test.name == name {
-			qaruntest(test, true)
+			qaruntest(test, ex, true)
 			return
 		}
 	}
 }
 
-func extractTest(test test, writeextract bool) ([]byte, string) {
+// Extracts the text of a test with ex, the extractor of ExtractEx if ex is nil
+func extractTest(test test, ex sandblast.Extractor, writeextract bool) ([]byte, string) {
 	in, err := test.input.Open()
 	must(err)
 	defer in.Close()
@@ -157,8 +166,25 @@ func extractTest(test test, writeextract bool) ([]byte, string) {
 	node, err := html.Parse(r)
 	must(err)
 
+	if ex != nil {
+		opts := &sandblast.Options{Extractor: ex}
+		if writeextract {
+			opts.Flags |= sandblast.Explain
+		}
+		result, err := sandblast.ExtractWithOptions(node, opts)
+		if result == nil {
+			must(err)
+		}
+		for _, d := range result.Log {
+			fmt.Printf("%s %s dropped=%v <%s> %s\n", d.Stage, d.Kind, d.Dropped, d.Text, d.Reason)
+		}
+		return body, result.Text
+	}
+
 	_, output, simplified, flattened, cleaned, err := sandblast.ExtractEx(node, 0)
-	must(err)
+	if !sandblast.IsPartialError(err) {
+		must(err)
+	}
 
 	if writeextract {
 		fmt.Printf("SIMPLIFIED:\n%s\n", simplified.DebugString())
@@ -169,16 +195,19 @@ func extractTest(test test, writeextract bool) ([]byte, string) {
 	return body, output
 }
 
-func qaruntest(test test, writein bool) bool {
-	body, output := extractTest(test, writein)
-
+func readTarget(test test) string {
 	tgt, err := test.target.Open()
 	must(err)
 	defer tgt.Close()
 
 	tgtbody, err := ioutil.ReadAll(tgt)
 	must(err)
-	target := strings.TrimSpace(string(tgtbody))
+	return strings.TrimSpace(string(tgtbody))
+}
+
+func qaruntest(test test, ex sandblast.Extractor, writein bool) bool {
+	body, output := extractTest(test, ex, writein)
+	target := readTarget(test)
 
 	a := strings.TrimSpace(string(collapseWhitespace([]rune(target))))
 	b := strings.TrimSpace(string(collapseWhitespace([]rune(output))))
@@ -208,6 +237,228 @@ func qaruntest(test test, writein bool) bool {
 	return true
 }
 
+// Returns the extractor named by the i-th command line argument, or the model loaded from the file it names, nil if there is no such argument
+func extractorArg(i int) sandblast.Extractor {
+	if len(os.Args) <= i {
+		return nil
+	}
+	if strings.HasSuffix(os.Args[i], ".json") {
+		return loadModel(os.Args[i])
+	}
+	ex := sandblast.ExtractorByName(os.Args[i])
+	if ex == nil {
+		fmt.Fprintf(os.Stderr, "unknown extractor %s\n", os.Args[i])
+		usage()
+	}
+	return ex
+}
+
+// Returns precision, recall and F1 score of the words of output with respect to the words of target
+func wordScores(output, target string) (precision, recall, f1 float64) {
+	count := map[string]int{}
+	for _, w := range strings.Fields(target) {
+		count[w]++
+	}
+	outwords := strings.Fields(output)
+	common := 0
+	for _, w := range outwords {
+		if count[w] > 0 {
+			count[w]--
+			common++
+		}
+	}
+	ntarget := len(strings.Fields(target))
+	switch {
+	case len(outwords) == 0 && ntarget == 0:
+		return 1, 1, 1
+	case len(outwords) == 0 || ntarget == 0 || common == 0:
+		return 0, 0, 0
+	}
+	precision = float64(common) / float64(len(outwords))
+	recall = float64(common) / float64(ntarget)
+	return precision, recall, 2 * precision * recall / (precision + recall)
+}
+
+// Evaluates every extractor on the dataset, reporting the number of exact matches and the mean word precision, recall and F1 score
+func qaeval(datapath string, model sandblast.Extractor) {
+	dataset := openDataset(datapath)
+	defer dataset.Close()
+
+	extractors := sandblast.Extractors()
+	if model != nil {
+		extractors = append(extractors, model)
+	}
+
+	fmt.Printf("%-12s %8s %9s %9s %9s\n", "extractor", "exact", "precision", "recall", "f1")
+	for _, ex := range extractors {
+		exact := 0
+		var precision, recall, f1 float64
+		for _, test := range dataset.tests {
+			_, output := extractTest(test, ex, false)
+			a := strings.TrimSpace(string(collapseWhitespace([]rune(readTarget(test)))))
+			b := strings.TrimSpace(string(collapseWhitespace([]rune(output))))
+			if a == b {
+				exact++
+			}
+			p, r, f := wordScores(b, a)
+			precision += p
+			recall += r
+			f1 += f
+		}
+		n := float64(len(dataset.tests))
+		fmt.Printf("%-12s %4d/%-4d %9.3f %9.3f %9.3f\n", ex.Name(), exact, len(dataset.tests), precision/n, recall/n, f1/n)
+	}
+}
+
+func loadModel(path string) *sandblast.Model {
+	fh, err := os.Open(path)
+	must(err)
+	defer fh.Close()
+	m, err := sandblast.LoadModel(fh)
+	must(err)
+	return m
+}
+
+// Trains a model on the dataset and writes it to outpath
+func qatrain(datapath, outpath string) {
+	dataset := openDataset(datapath)
+	defer dataset.Close()
+
+	var tr sandblast.Trainer
+	for _, test := range dataset.tests {
+		in, err := test.input.Open()
+		must(err)
+		body, err := ioutil.ReadAll(in)
+		in.Close()
+		must(err)
+
+		e, _, _ := charset.DetermineEncoding(body, "UTF-8")
+		node, err := html.Parse(transform.NewReader(bytes.NewReader(body), e.NewDecoder()))
+		must(err)
+		if err := tr.Add(node, readTarget(test)); err != nil {
+			fmt.Printf("Skipping %s: %v\n", test.name, err)
+		}
+	}
+
+	examples, content := tr.Len()
+	fmt.Printf("Training on %d blocks (%d content)\n", examples, content)
+	m := tr.Train()
+	fmt.Printf("Accuracy on the training blocks: %.3f\n", tr.Accuracy(m))
+
+	out, err := os.Create(outpath)
+	must(err)
+	defer out.Close()
+	must(m.Save(out))
+}
+
+// A document of the dataset with its target text
+type tuneDoc struct {
+	node   *html.Node
+	target string
+}
+
+// A threshold being tuned: get and set access it, the candidate values are the current one plus each step, within min and max
+type tuneParam struct {
+	name     string
+	get      func(t *sandblast.Thresholds) float64
+	set      func(t *sandblast.Thresholds, v float64)
+	steps    []float64
+	min, max float64
+}
+
+var tuneParams = []tuneParam{
+	{"LinkBlob", func(t *sandblast.Thresholds) float64 { return t.LinkBlob }, func(t *sandblast.Thresholds, v float64) { t.LinkBlob = v }, []float64{-0.1, -0.05, 0.05, 0.1}, 0.3, 1},
+	{"LinkListItem", func(t *sandblast.Thresholds) float64 { return t.LinkListItem }, func(t *sandblast.Thresholds, v float64) { t.LinkListItem = v }, []float64{-0.1, -0.05, 0.05, 0.1}, 0.3, 1},
+	{"LinkListRatio", func(t *sandblast.Thresholds) float64 { return t.LinkListRatio }, func(t *sandblast.Thresholds, v float64) { t.LinkListRatio = v }, []float64{-0.1, -0.05, 0.05, 0.1}, 0.3, 1},
+	{"LinkListSize", func(t *sandblast.Thresholds) float64 { return float64(t.LinkListSize) }, func(t *sandblast.Thresholds, v float64) { t.LinkListSize = int(v) }, []float64{-2, -1, 1, 2}, 2, 20},
+	{"ShortLength", func(t *sandblast.Thresholds) float64 { return float64(t.ShortLength) }, func(t *sandblast.Thresholds, v float64) { t.ShortLength = int(v) }, []float64{-5, -2, 2, 5}, 1, 100},
+	{"OkLength", func(t *sandblast.Thresholds) float64 { return float64(t.OkLength) }, func(t *sandblast.Thresholds, v float64) { t.OkLength = int(v) }, []float64{-10, -5, 5, 10}, 10, 300},
+}
+
+// Returns the mean word F1 score and the number of exact matches of the extraction of docs with the specified thresholds
+func tuneScore(docs []tuneDoc, t *sandblast.Thresholds, ex sandblast.Extractor, lang string) (f1 float64, exact int) {
+	for _, doc := range docs {
+		output := ""
+		r, _ := sandblast.ExtractWithOptions(doc.node, &sandblast.Options{Thresholds: t, Extractor: ex, Language: lang})
+		if r != nil {
+			output = strings.TrimSpace(string(collapseWhitespace([]rune(r.Text))))
+		}
+		if output == doc.target {
+			exact++
+		}
+		_, _, f := wordScores(output, doc.target)
+		f1 += f
+	}
+	return f1 / float64(len(docs)), exact
+}
+
+// Searches the thresholds that maximize the mean word F1 score on the dataset by hill climbing, starting from the default thresholds.
+// The documents are extracted as if they were written in lang, if it isn't empty, otherwise with the profile of their language:
+// thresholds that weren't changed yet are left to zero, so that the lengths keep the default of each document.
+func qatune(datapath string, ex sandblast.Extractor, lang string) {
+	dataset := openDataset(datapath)
+	defer dataset.Close()
+
+	docs := []tuneDoc{}
+	for _, test := range dataset.tests {
+		in, err := test.input.Open()
+		must(err)
+		body, err := ioutil.ReadAll(in)
+		in.Close()
+		must(err)
+
+		e, _, _ := charset.DetermineEncoding(body, "UTF-8")
+		node, err := html.Parse(transform.NewReader(bytes.NewReader(body), e.NewDecoder()))
+		must(err)
+		docs = append(docs, tuneDoc{node, strings.TrimSpace(string(collapseWhitespace([]rune(readTarget(test)))))})
+	}
+	if len(docs) == 0 {
+		fmt.Printf("Empty dataset\n")
+		return
+	}
+
+	best := sandblast.Thresholds{}
+	defaults := sandblast.ThresholdsFor(lang) // the steps of zero fields start from these
+	bestScore, bestExact := tuneScore(docs, &best, ex, lang)
+	fmt.Printf("Default thresholds: f1 %.4f, exact %d/%d\n", bestScore, bestExact, len(docs))
+
+	for round := 1; round <= 20; round++ {
+		improved := false
+		for _, param := range tuneParams {
+			for _, step := range param.steps {
+				v := param.get(&best)
+				if v == 0 {
+					v = param.get(&defaults)
+				}
+				v += step
+				if v < param.min || v > param.max {
+					continue
+				}
+				candidate := best
+				param.set(&candidate, v)
+				score, exact := tuneScore(docs, &candidate, ex, lang)
+				if score > bestScore {
+					fmt.Printf("Round %d: %s = %g, f1 %.4f, exact %d/%d\n", round, param.name, v, score, exact, len(docs))
+					best, bestScore, bestExact = candidate, score, exact
+					improved = true
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+
+	fmt.Printf("\nBest thresholds: f1 %.4f, exact %d/%d\n", bestScore, bestExact, len(docs))
+	for _, param := range tuneParams {
+		if v := param.get(&best); v != 0 {
+			fmt.Printf("\t%s = %g\n", param.name, v)
+		} else {
+			fmt.Printf("\t%s = default\n", param.name)
+		}
+	}
+}
+
 func qarebuild(datapath, outpath string) {
 	dataset := openDataset(datapath)
 	defer dataset.Close()
@@ -224,7 +475,7 @@ func qarebuild(datapath, outpath string) {
 	for _, test := range dataset.tests {
 		fmt.Printf("processing %s\n", test.name)
 		copyFile(outzip, fmt.Sprintf("%s.html", test.name), test.input)
-		_, output := extractTest(test, false)
+		_, output := extractTest(test, nil, false)
 		w, err := outzip.Create(fmt.Sprintf("%s.target", test.name))
 		must(err)
 		_, err = io.WriteString(w, output)
@@ -251,9 +502,31 @@ func main() {
 		if len(os.Args) < 3 {
 			usage()
 		}
-		qarun(os.Args[2])
+		qarun(os.Args[2], extractorArg(3))
 	case "one":
-		qaone(os.Args[2], os.Args[3])
+		if len(os.Args) < 4 {
+			usage()
+		}
+		qaone(os.Args[2], os.Args[3], extractorArg(4))
+	case "eval":
+		if len(os.Args) < 3 {
+			usage()
+		}
+		qaeval(os.Args[2], extractorArg(3))
+	case "train":
+		if len(os.Args) < 4 {
+			usage()
+		}
+		qatrain(os.Args[2], os.Args[3])
+	case "tune":
+		if len(os.Args) < 3 {
+			usage()
+		}
+		lang := ""
+		if len(os.Args) > 4 {
+			lang = os.Args[4]
+		}
+		qatune(os.Args[2], extractorArg(3), lang)
 	case "rebuild":
 		if len(os.Args) < 4 {
 			usage()
@@ -0,0 +1,189 @@
+package sandblast
+
+import (
+	"golang.org/x/net/html"
+	"math"
+	"strings"
+)
+
+// Words that, found in the class or id attributes of an element, make it more or less likely to contain the content
+var (
+	readabilityPositive = []string{"article", "body", "content", "entry", "hentry", "main", "page", "post", "text", "blog", "story"}
+	readabilityNegative = []string{"comment", "com-", "contact", "foot", "footer", "footnote", "masthead", "media", "meta", "outbrain", "promo", "related", "scroll", "shoutbox", "sidebar", "sponsor", "shopping", "tags", "tool", "widget"}
+)
+
+type readabilityExtractor struct{}
+
+func (readabilityExtractor) Name() string { return "readability" }
+
+// Classifies blocks like Readability: every paragraph adds a score, based on its length and number of commas,
+// to its parent and grandparent elements; the element with the highest score, weighted by its link density, is the content
+// together with its siblings with a good enough score.
+// Trees without a document, like the ones passed to ExtractTree, are cleaned with the default rules.
+func (readabilityExtractor) Classify(s *BlockSet) {
+	blocks := s.parent.childs
+	paras := make([]*html.Node, len(blocks))
+	found := false
+	for i, block := range blocks {
+		if block == nil {
+			continue
+		}
+		if sources := block.allSources(); len(sources) > 0 {
+			paras[i] = paragraphNode(sources[0])
+			found = true
+		}
+	}
+	if !found {
+		cleanRules(s)
+		return
+	}
+
+	scores := map[*html.Node]float64{}
+	candidates := []*html.Node{}
+	addScore := func(node *html.Node, score float64) {
+		if node == nil || node.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[node]; !ok {
+			scores[node] = readabilityBaseScore(node)
+			candidates = append(candidates, node)
+		}
+		scores[node] += score
+	}
+	for i, block := range blocks {
+		if paras[i] == nil {
+			continue
+		}
+		text := blockText(block)
+		length := textLength(text)
+		if length < 25 {
+			continue
+		}
+		score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，")+strings.Count(text, "、")) + math.Min(3, float64(length/100))
+		addScore(paras[i].Parent, score)
+		if paras[i].Parent != nil {
+			addScore(paras[i].Parent.Parent, score/2)
+		}
+	}
+	if len(candidates) == 0 {
+		cleanRules(s)
+		return
+	}
+
+	// link density of the candidates, from the blocks inside them
+	chars, linkChars := map[*html.Node]float64{}, map[*html.Node]float64{}
+	for i, block := range blocks {
+		if paras[i] == nil {
+			continue
+		}
+		length := float64(textLength(blockText(block)))
+		for node := paras[i]; node != nil; node = node.Parent {
+			if _, ok := scores[node]; ok {
+				chars[node] += length
+				linkChars[node] += length * float64(block.linkPart)
+			}
+		}
+	}
+	var top *html.Node
+	for _, node := range candidates {
+		if chars[node] > 0 {
+			scores[node] *= 1 - linkChars[node]/chars[node]
+		}
+		if top == nil || scores[node] > scores[top] {
+			top = node
+		}
+	}
+
+	threshold := math.Max(10, scores[top]*0.2)
+	content := []*html.Node{top}
+	if top.Parent != nil {
+		for sibling := top.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+			if score, ok := scores[sibling]; ok && sibling != top && score >= threshold {
+				content = append(content, sibling)
+			}
+		}
+	}
+
+	for i, block := range blocks {
+		if s.Decided(i) || block.span > 0 {
+			continue
+		}
+		if paras[i] == nil {
+			s.Drop(i, "not part of the document")
+			continue
+		}
+		keep := false
+		for _, node := range content {
+			if isDescendant(paras[i], node) {
+				keep = true
+				break
+			}
+		}
+		switch {
+		case keep:
+			s.Keep(i, "inside the content element (score %.1f)", scores[top])
+		case paras[i].Parent == top.Parent && textLength(blockText(block)) > 80 && block.linkPart < 0.25:
+			s.Keep(i, "paragraph next to the content element")
+		default:
+			s.Drop(i, "outside of the content element")
+		}
+	}
+}
+
+// Returns the closest element containing node that isn't an inline or formatting element
+func paragraphNode(node *html.Node) *html.Node {
+	if node.Type != html.ElementNode {
+		node = node.Parent
+	}
+	for node != nil && node.Parent != nil && node.Parent.Type == html.ElementNode {
+		if kind := getNodeKind(node); kind != _K_INLINE && kind != _K_FORMATTING {
+			break
+		}
+		node = node.Parent
+	}
+	return node
+}
+
+// Returns the initial score of a candidate element, from its tag and its class and id attributes
+func readabilityBaseScore(node *html.Node) float64 {
+	score := 0.0
+	switch strings.ToLower(node.Data) {
+	case "div", "article", "main", "section":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+	for _, attr := range []string{"class", "id"} {
+		val := strings.ToLower(getAttribute(node, attr))
+		if val == "" {
+			continue
+		}
+		for _, hint := range readabilityNegative {
+			if strings.Contains(val, hint) {
+				score -= 25
+				break
+			}
+		}
+		for _, hint := range readabilityPositive {
+			if strings.Contains(val, hint) {
+				score += 25
+				break
+			}
+		}
+	}
+	return score
+}
+
+// Returns true if node is ancestor or one of its descendants
+func isDescendant(node, ancestor *html.Node) bool {
+	for ; node != nil; node = node.Parent {
+		if node == ancestor {
+			return true
+		}
+	}
+	return false
+}
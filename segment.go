@@ -0,0 +1,309 @@
+package sandblast
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A paragraph of the extracted text
+type Paragraph struct {
+	Text      string   // Text of the paragraph
+	Heading   int      // Level of the heading (1 to 6) if the paragraph is a heading, 0 otherwise
+	ID        string   // Anchor of the heading, the same as the ID of its entry of Result.TOC, only set for headings
+	Caption   bool     // The paragraph is the caption of a table, the paragraphs of the table follow it
+	Figure    *Figure  // Figure represented by the paragraph, Text is its caption
+	Media     *Media   // Media represented by the paragraph, Text is its title
+	Sentences []string // Sentences of the paragraph, only set when extracting with SplitSentences
+}
+
+// Returns the paragraphs of a cleaned tree, ids are the ids of the headings (see headingIds)
+func buildParagraphs(cleaned *element, flags Flags, ids map[*element]string) []*Paragraph {
+	if cleaned == nil {
+		return nil
+	}
+
+	blocks := cleaned.childs
+	if blocks == nil {
+		blocks = []*element{cleaned}
+	}
+
+	r := []*Paragraph{}
+	for _, block := range blocks {
+		if block == nil {
+			continue
+		}
+		p := &Paragraph{Text: blockText(block), Figure: block.figure, Media: block.media}
+		if p.Media != nil {
+			p.Text = p.Media.Title
+		}
+		if p.Text == "" && p.Figure == nil && p.Media == nil {
+			continue
+		}
+		switch block.tag {
+		case "~header":
+			p.Heading = block.headingLevel()
+			p.ID = ids[block]
+		case "~caption":
+			p.Caption = block.span > 0
+		}
+		if flags&SplitSentences != 0 {
+			p.Sentences = splitSentences(p.Text)
+		}
+		r = append(r, p)
+	}
+	return r
+}
+
+// Words that are usually followed by a period without ending a sentence
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "prof": true, "sr": true, "jr": true, "st": true,
+	"mt": true, "gen": true, "col": true, "lt": true, "sgt": true, "capt": true, "rev": true, "gov": true, "sen": true, "rep": true,
+	"vs": true, "e.g": true, "i.e": true, "cf": true, "al": true, "ca": true, "approx": true,
+	"inc": true, "ltd": true, "co": true, "corp": true, "dept": true, "est": true,
+	"no": true, "nos": true, "fig": true, "figs": true, "vol": true, "p": true, "pp": true, "ch": true,
+	"jan": true, "feb": true, "mar": true, "apr": true, "jun": true, "jul": true, "aug": true, "sep": true, "sept": true, "oct": true, "nov": true, "dec": true,
+}
+
+// Returns true for sentence terminators that must be followed by a space to end a sentence
+func isSpacedTerminator(ch rune) bool {
+	switch ch {
+	case '.', '!', '?', '…', '‼', '⁇', '⁈', '⁉':
+		return true
+	}
+	return false
+}
+
+// Returns true for sentence terminators of scripts that don't need a space after them
+func isFullTerminator(ch rune) bool {
+	switch ch {
+	case '。', '｡', '！', '？', '．', // CJK
+		'।', '॥', // Devanagari
+		'؟', '۔', // Arabic, Urdu
+		'։',      // Armenian
+		'።', '፧': // Ethiopic
+		return true
+	}
+	return false
+}
+
+// Returns true for characters that can follow a sentence terminator without starting a new sentence (closing quotes and brackets)
+func isCloser(ch rune) bool {
+	return ch == '"' || ch == '\'' || unicode.In(ch, unicode.Pe, unicode.Pf)
+}
+
+// Splits text into sentences
+func splitSentences(text string) []string {
+	in := []rune(text)
+	r := []string{}
+	start := 0
+
+	push := func(end int) {
+		if s := strings.TrimSpace(string(in[start:end])); s != "" {
+			r = append(r, s)
+		}
+		start = end
+	}
+
+	for i := 0; i < len(in); i++ {
+		ch := in[i]
+		full := isFullTerminator(ch)
+		if !full && !isSpacedTerminator(ch) {
+			continue
+		}
+
+		// consume repeated terminators and closing punctuation
+		end := i + 1
+		for end < len(in) && (isSpacedTerminator(in[end]) || isFullTerminator(in[end])) {
+			end++
+		}
+		for end < len(in) && isCloser(in[end]) {
+			end++
+		}
+
+		if full {
+			push(end)
+			i = end - 1
+			continue
+		}
+
+		if end < len(in) && !unicode.IsSpace(in[end]) {
+			i = end - 1
+			continue
+		}
+
+		next := end
+		for next < len(in) && unicode.IsSpace(in[next]) {
+			next++
+		}
+		if next < len(in) && unicode.IsLower(in[next]) {
+			i = end - 1
+			continue
+		}
+		if ch == '.' && end == i+1 {
+			if word := wordBefore(in, i); isAbbreviation(word) || (isSingleUpper(word) && isInitial(in, i, next)) {
+				i = end - 1
+				continue
+			}
+		}
+
+		push(end)
+		i = end - 1
+	}
+
+	push(len(in))
+	return r
+}
+
+// Returns the word ending at position i of in
+func wordBefore(in []rune, i int) string {
+	s := i
+	for s > 0 && !unicode.IsSpace(in[s-1]) {
+		s--
+	}
+	for s < i && !unicode.IsLetter(in[s]) && !unicode.IsDigit(in[s]) {
+		s++
+	}
+	return string(in[s:i])
+}
+
+// Returns true if word followed by a period is likely an abbreviation rather than the end of a sentence
+func isAbbreviation(word string) bool {
+	if word == "" {
+		return false
+	}
+	lword := strings.ToLower(word)
+	if abbreviations[lword] {
+		return true
+	}
+	if strings.Index(word, ".") >= 0 {
+		// dotted abbreviations, as in "U.S.", but not numbers
+		if strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+			return false
+		}
+		for _, part := range strings.Split(word, ".") {
+			if len([]rune(part)) > 2 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func isSingleUpper(word string) bool {
+	rword := []rune(word)
+	return len(rword) == 1 && unicode.IsUpper(rword[0])
+}
+
+// Returns true if the single letter before the period at position i of in is an initial, as in "J. R. R. Tolkien" or "John F. Kennedy",
+// rather than the end of a sentence, as in "vitamin C. It": it is followed by another initial or preceded by an initial or a capitalized word.
+// next is the position of the word after the period.
+func isInitial(in []rune, i, next int) bool {
+	if next+1 < len(in) && unicode.IsUpper(in[next]) && in[next+1] == '.' {
+		return true
+	}
+	j := i - 1
+	for j > 0 && unicode.IsSpace(in[j-1]) {
+		j--
+	}
+	if j == i-1 || j == 0 {
+		return false
+	}
+	if in[j-1] == '.' {
+		return isSingleUpper(wordBefore(in, j-1))
+	}
+	prev := []rune(wordBefore(in, j))
+	return len(prev) > 0 && unicode.IsUpper(prev[0])
+}
+
+// Returns true for characters of scripts that don't separate words with spaces and whose characters are counted as words
+func isIdeographic(ch rune) bool {
+	return unicode.In(ch, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// Splits text into words: runs of letters and digits. Scripts written without spaces (see isUnspaced) aren't segmented:
+// each of their characters, together with the combining marks following it, is a word on its own.
+// For Chinese and Japanese this is the usual convention, for Thai, Lao, Khmer, Myanmar and Tibetan it is only an approximation
+// (segmenting them needs a dictionary), use wordCount to count words.
+func splitWords(text string) []string {
+	r := []string{}
+	start := -1
+	unspaced := false // the last word is a character of a script written without spaces
+	for i, ch := range text {
+		if unspaced && unicode.IsMark(ch) {
+			r[len(r)-1] += string(ch)
+			continue
+		}
+		if isUnspaced(ch) {
+			if start >= 0 {
+				r = append(r, text[start:i])
+				start = -1
+			}
+			r = append(r, string(ch))
+			unspaced = true
+			continue
+		}
+		unspaced = false
+		if unicode.IsLetter(ch) || unicode.IsDigit(ch) || unicode.IsMark(ch) || (start >= 0 && (ch == '\'' || ch == '’') && i+1 < len(text)) {
+			if start < 0 {
+				start = i
+			}
+		} else if start >= 0 {
+			r = append(r, text[start:i])
+			start = -1
+		}
+	}
+	if start >= 0 {
+		r = append(r, text[start:])
+	}
+	for i := range r {
+		r[i] = strings.TrimRight(r[i], "'’")
+	}
+	return r
+}
+
+// Average length in characters of the words of the scripts written without spaces that aren't ideographic
+const _UNSPACED_WORD_LENGTH = 4
+
+// Returns the number of words of text: the words of splitWords, except that the words of Thai, Lao, Khmer, Myanmar
+// and Tibetan (which splitWords doesn't segment) are estimated from the number of their characters
+func wordCount(text string) int {
+	n, unspaced := 0, 0
+	for _, w := range splitWords(text) {
+		if ch, _ := utf8.DecodeRuneInString(w); isUnspaced(ch) && !isIdeographic(ch) {
+			unspaced++
+		} else {
+			n++
+		}
+	}
+	return n + (unspaced+_UNSPACED_WORD_LENGTH-1)/_UNSPACED_WORD_LENGTH
+}
+
+// Returns true for characters of scripts that are written without spaces between words
+func isUnspaced(ch rune) bool {
+	return isIdeographic(ch) || unicode.In(ch, unicode.Thai, unicode.Lao, unicode.Khmer, unicode.Myanmar, unicode.Tibetan)
+}
+
+// Returns the length of text in characters, combining marks and link markers are not counted so that
+// the length of scripts like Thai or Devanagari approximates the number of grapheme clusters
+func textLength(text string) int {
+	n := 0
+	for _, ch := range text {
+		if ch == rune(_LINK_START[0]) || ch == rune(_LINK_END[0]) || unicode.In(ch, unicode.Mn, unicode.Me) {
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+// Returns true if text contains more than one word: it contains whitespace or it is written in a script that doesn't separate words with spaces
+func hasWordBreaks(text string) bool {
+	for _, ch := range text {
+		if unicode.IsSpace(ch) || isUnspaced(ch) {
+			return true
+		}
+	}
+	return false
+}
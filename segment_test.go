@@ -0,0 +1,82 @@
+package sandblast
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	tf := func(in string, target string) {
+		out := strings.Join(splitWords(in), "|")
+		if out != target {
+			t.Errorf("Error splitting words of <%s>\n\tgot <%s>\n\texpected <%s>\n", in, out, target)
+		}
+	}
+	tf("Hello, world!", "Hello|world")
+	tf("It's 42 o'clock.", "It's|42|o'clock")
+	tf("Go言語です", "Go|言|語|で|す")
+}
+
+func TestWordCount(t *testing.T) {
+	tf := func(in string, target int) {
+		if out := wordCount(in); out != target {
+			t.Errorf("Error counting words of <%s>\n\tgot <%d>\n\texpected <%d>\n", in, out, target)
+		}
+	}
+	tf("Hello, world!", 2)
+	tf("Go言語です", 5)
+	tf("ภาษาไทย", 2)
+	tf("ภาษาไทย is Thai", 4)
+}
+
+func TestSplitSentences(t *testing.T) {
+	tf := func(in string, target ...string) {
+		out := splitSentences(in)
+		if strings.Join(out, "|") != strings.Join(target, "|") {
+			t.Errorf("Error splitting sentences on <%s>\n\tgot <%s>\n\texpected <%s>\n", in, strings.Join(out, "|"), strings.Join(target, "|"))
+		}
+	}
+	tf("First sentence. Second sentence! Third?", "First sentence.", "Second sentence!", "Third?")
+	tf("Mr. Smith met Dr. Jones on Jan. 5th. They talked.", "Mr. Smith met Dr. Jones on Jan. 5th.", "They talked.")
+	tf("J. R. R. Tolkien wrote it in the U.S. during the war.", "J. R. R. Tolkien wrote it in the U.S. during the war.")
+	tf("Pi is 3.14 and e.g. example.com is a domain. Really.", "Pi is 3.14 and e.g. example.com is a domain.", "Really.")
+	tf("He said \"Stop.\" Then he left... And came back.", "He said \"Stop.\"", "Then he left...", "And came back.")
+	tf("これはペンです。あれは本です！本当？", "これはペンです。", "あれは本です！", "本当？")
+	tf("यह एक वाक्य है। यह दूसरा है।", "यह एक वाक्य है।", "यह दूसरा है।")
+	tf("no terminator at the end", "no terminator at the end")
+	tf("The economy grew by 2.5. Then it shrank.", "The economy grew by 2.5.", "Then it shrank.")
+	tf("Take vitamin C. It helps.", "Take vitamin C.", "It helps.")
+	tf("President John F. Kennedy spoke. Plan b. is next.", "President John F. Kennedy spoke.", "Plan b. is next.")
+}
+
+func TestParagraphs(t *testing.T) {
+	node, err := html.Parse(strings.NewReader("<html><body><h2>The heading</h2><p>This is the first sentence of the paragraph. This is the second one, written by Mr. Smith.</p><p>This paragraph has a single sentence and it is long enough.</p></body></html>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ExtractResult(node, SplitSentences)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := []Paragraph{
+		{Text: "The heading", Heading: 2, Sentences: []string{"The heading"}},
+		{Text: "This is the first sentence of the paragraph. This is the second one, written by Mr. Smith.", Sentences: []string{"This is the first sentence of the paragraph.", "This is the second one, written by Mr. Smith."}},
+		{Text: "This paragraph has a single sentence and it is long enough.", Sentences: []string{"This paragraph has a single sentence and it is long enough."}},
+	}
+	if len(r.Paragraphs) != len(target) {
+		t.Fatalf("Wrong number of paragraphs\n\tgot <%d>\n\texpected <%d>\n", len(r.Paragraphs), len(target))
+	}
+	for i, p := range r.Paragraphs {
+		if p.Text != target[i].Text || p.Heading != target[i].Heading || strings.Join(p.Sentences, "|") != strings.Join(target[i].Sentences, "|") {
+			t.Errorf("Error in paragraph %d\n\tgot <%+v>\n\texpected <%+v>\n", i, *p, target[i])
+		}
+	}
+
+	r, _ = ExtractResult(node, 0)
+	for i, p := range r.Paragraphs {
+		if p.Sentences != nil {
+			t.Errorf("Sentences of paragraph %d set without SplitSentences", i)
+		}
+	}
+}
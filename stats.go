@@ -0,0 +1,86 @@
+package sandblast
+
+import (
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	_DEFAULT_WORDS_PER_MINUTE      = 230
+	_DEFAULT_IDEOGRAPHS_PER_MINUTE = 500
+)
+
+// Reading statistics of the extracted text
+type Stats struct {
+	Words       int           // Number of words, each Chinese or Japanese character counts as a word and the words of Thai, Lao and Khmer are estimated from their length
+	Characters  int           // Number of characters, excluding whitespace
+	ReadingTime time.Duration // Estimated reading time, see Options.WordsPerMinute and Options.IdeographsPerMinute
+	Paragraphs  int           // Number of paragraphs of text, excluding headings, captions, figures and media
+	Headings    int           // Number of headings
+	Links       int           // Number of links
+	LinkDensity float64       // Fraction of the text that is inside links
+}
+
+// Returns the reading statistics of the paragraphs of a cleaned tree
+func buildStats(cleaned *element, paragraphs []*Paragraph, opts *Options) *Stats {
+	wpm, ipm := opts.WordsPerMinute, opts.IdeographsPerMinute
+	if wpm <= 0 {
+		wpm = _DEFAULT_WORDS_PER_MINUTE
+	}
+	if ipm <= 0 {
+		ipm = _DEFAULT_IDEOGRAPHS_PER_MINUTE
+	}
+
+	r := &Stats{}
+	words, unspaced := 0, 0 // words of scripts written with spaces, characters of the others
+	for _, p := range paragraphs {
+		if p.Heading > 0 {
+			r.Headings++
+		}
+		spaced := 0
+		for _, w := range splitWords(p.Text) {
+			if ch, _ := utf8.DecodeRuneInString(w); isUnspaced(ch) {
+				unspaced++
+			} else {
+				spaced++
+			}
+		}
+		words += spaced
+		r.Words += wordCount(p.Text)
+		for _, ch := range p.Text {
+			if !unicode.IsSpace(ch) {
+				r.Characters++
+			}
+		}
+	}
+
+	minutes := float64(words)/float64(wpm) + float64(unspaced)/float64(ipm)
+	r.ReadingTime = time.Duration(minutes * float64(time.Minute)).Round(time.Second)
+
+	if cleaned != nil {
+		blocks := cleaned.childs
+		if blocks == nil {
+			blocks = []*element{cleaned}
+		}
+		for _, block := range blocks {
+			if block != nil && block.tag == "~textblock" && blockText(block) != "" {
+				r.Paragraphs++
+			}
+		}
+	}
+
+	var linkChars, chars float64
+	walkElements(cleaned, func(e *element) {
+		if e.childs == nil {
+			r.Links += len(e.hrefs)
+			length := float64(textLength(e.content))
+			linkChars += float64(e.linkPart) * length
+			chars += length
+		}
+	})
+	if chars > 0 {
+		r.LinkDensity = linkChars / chars
+	}
+	return r
+}
@@ -0,0 +1,47 @@
+package sandblast
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	tf := func(doc string, opts *Options, target Stats) {
+		node, err := html.Parse(strings.NewReader(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, _ := ExtractWithOptions(node, opts)
+		out := *r.Stats
+		out.LinkDensity = float64(int(out.LinkDensity*100)) / 100
+		if out != target {
+			t.Errorf("Error computing statistics of <%s>\n\tgot <%+v>\n\texpected <%+v>\n", doc, out, target)
+		}
+	}
+	const doc = "<html><body><h1>The heading of the article</h1><p>This is the first paragraph of the article, it is long enough to be kept.</p><p>This is the second paragraph, it contains <a href='http://example.com'>a link</a> somewhere.</p></body></html>"
+	tf(doc, &Options{}, Stats{Words: 30, Characters: 131, ReadingTime: 8 * time.Second, Paragraphs: 2, Headings: 1, Links: 1, LinkDensity: 0.03})
+	tf(doc, &Options{WordsPerMinute: 60}, Stats{Words: 30, Characters: 131, ReadingTime: 30 * time.Second, Paragraphs: 2, Headings: 1, Links: 1, LinkDensity: 0.03})
+
+	// figures and media aren't paragraphs
+	const media = "<html><body><p>This is the first paragraph of the article, it is long enough to be kept.</p><figure><img src='a.png'><figcaption>The caption of the figure</figcaption></figure><iframe src='https://www.youtube.com/embed/abc'></iframe><p>This is the second paragraph of the article, it is long enough to be kept.</p></body></html>"
+	tf(media, &Options{}, Stats{Words: 35, Characters: 140, ReadingTime: 9 * time.Second, Paragraphs: 2})
+
+	paragraphs := []*Paragraph{{Text: "日本語の文章です。"}, {Text: "Some english words"}}
+	out := buildStats(nil, paragraphs, &Options{WordsPerMinute: 60, IdeographsPerMinute: 120})
+	if out.Words != 11 || out.Characters != 25 || out.ReadingTime != 7*time.Second {
+		t.Errorf("Error computing statistics of mixed scripts\n\tgot <%+v>\n", *out)
+	}
+
+	// Thai isn't segmented, its words are estimated from the 36 characters (with their combining marks) of the text
+	paragraphs = []*Paragraph{{Text: "ภาษาไทยเป็นภาษาที่สวยงามและมีประวัติยาวนาน"}}
+	out = buildStats(nil, paragraphs, &Options{IdeographsPerMinute: 120})
+	if out.Words != 9 || out.Characters != 42 || out.ReadingTime != 18*time.Second {
+		t.Errorf("Error computing statistics of Thai\n\tgot <%+v>\n", *out)
+	}
+
+	// link density is measured in characters, not bytes
+	const cyrillic = "<html><body><p>This paragraph is written in English and it ends with <a href='/'>ссылка на сайт</a></p></body></html>"
+	tf(cyrillic, &Options{}, Stats{Words: 13, Characters: 56, ReadingTime: 3 * time.Second, Paragraphs: 1, Links: 1, LinkDensity: 0.2})
+}
@@ -0,0 +1,115 @@
+package sandblast
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	_TEXTRANK_DAMPING    = 0.85
+	_TEXTRANK_ITERATIONS = 100
+	_TEXTRANK_EPSILON    = 1e-5
+)
+
+// Returns the n most important sentences of the paragraphs in document order.
+// Sentences are ranked with TextRank: a graph of sentences is built, with edges weighted by the words two sentences have in common, and sentences are scored with PageRank.
+func summarize(paragraphs []*Paragraph, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	sentences := []string{}
+	for _, p := range paragraphs {
+		if p.Heading > 0 || p.Caption || p.Figure != nil || p.Media != nil {
+			continue
+		}
+		if p.Sentences != nil {
+			sentences = append(sentences, p.Sentences...)
+		} else {
+			sentences = append(sentences, splitSentences(p.Text)...)
+		}
+	}
+	if len(sentences) <= n {
+		return sentences
+	}
+
+	bags := make([]map[string]int, len(sentences))
+	lens := make([]int, len(sentences))
+	for i := range sentences {
+		bags[i] = map[string]int{}
+		for _, w := range splitWords(strings.ToLower(sentences[i])) {
+			if utf8.RuneCountInString(w) > 1 || isUnspaced([]rune(w)[0]) {
+				bags[i][w]++
+				lens[i]++
+			}
+		}
+	}
+
+	// similarity between sentences as defined by the TextRank paper
+	weights := make([][]float64, len(sentences))
+	totals := make([]float64, len(sentences))
+	for i := range sentences {
+		weights[i] = make([]float64, len(sentences))
+	}
+	for i := range sentences {
+		for j := i + 1; j < len(sentences); j++ {
+			if lens[i] <= 1 || lens[j] <= 1 {
+				continue
+			}
+			common := 0
+			for w, c := range bags[i] {
+				if c2, ok := bags[j][w]; ok {
+					if c2 < c {
+						c = c2
+					}
+					common += c
+				}
+			}
+			if common == 0 {
+				continue
+			}
+			sim := float64(common) / (math.Log(float64(lens[i])) + math.Log(float64(lens[j])))
+			weights[i][j], weights[j][i] = sim, sim
+			totals[i] += sim
+			totals[j] += sim
+		}
+	}
+
+	scores := make([]float64, len(sentences))
+	for i := range scores {
+		scores[i] = 1
+	}
+	for it := 0; it < _TEXTRANK_ITERATIONS; it++ {
+		delta := 0.0
+		next := make([]float64, len(sentences))
+		for i := range sentences {
+			s := 0.0
+			for j := range sentences {
+				if weights[j][i] > 0 {
+					s += weights[j][i] / totals[j] * scores[j]
+				}
+			}
+			next[i] = (1 - _TEXTRANK_DAMPING) + _TEXTRANK_DAMPING*s
+			delta += math.Abs(next[i] - scores[i])
+		}
+		scores = next
+		if delta < _TEXTRANK_EPSILON {
+			break
+		}
+	}
+
+	idx := make([]int, len(sentences))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return scores[idx[a]] > scores[idx[b]] })
+	idx = idx[:n]
+	sort.Ints(idx)
+
+	r := make([]string, n)
+	for i := range idx {
+		r[i] = sentences[idx[i]]
+	}
+	return r
+}
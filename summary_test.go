@@ -0,0 +1,24 @@
+package sandblast
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	tf := func(paragraphs []*Paragraph, n int, target string) {
+		out := strings.Join(summarize(paragraphs, n), "|")
+		if out != target {
+			t.Errorf("Error summarizing to %d sentences\n\tgot <%s>\n\texpected <%s>\n", n, out, target)
+		}
+	}
+	paragraphs := []*Paragraph{
+		{Text: "Introduction", Heading: 1},
+		{Text: "The cat sat on the mat near the door. Dogs bark loudly at night."},
+		{Text: "The cat likes the mat because the mat is warm. Yesterday it rained."},
+		{Text: "A warm mat makes the cat happy."},
+	}
+	tf(paragraphs, 0, "")
+	tf(paragraphs, 2, "The cat sat on the mat near the door.|The cat likes the mat because the mat is warm.")
+	tf(paragraphs, 10, "The cat sat on the mat near the door.|Dogs bark loudly at night.|The cat likes the mat because the mat is warm.|Yesterday it rained.|A warm mat makes the cat happy.")
+}
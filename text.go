@@ -6,93 +6,204 @@ import (
 	"strings"
 )
 
-const _MAX_PROCESSING_DEPTH = 100
-
 type Flags int
 
 const (
-	KeepMenus     = Flags(1 << iota) // Not implemented
-	KeepLinks                        // Keeps link destinations for links embedded inside text blocks
-	KeepImages                       // Not implemented
-	MarkTitles                       // Not implemented
-	isDestructive                    // Intermediate values will be discarded (internal)
+	KeepMenus      = Flags(1 << iota) // Not implemented
+	KeepLinks                         // Keeps link destinations for links embedded inside text blocks
+	KeepImages                        // Keeps the source of images inside figures
+	MarkTitles                        // Not implemented
+	SplitSentences                    // Splits paragraphs into sentences (see Paragraph.Sentences)
+	MarkMedia                         // Adds placeholders for embedded media to the text
+	Explain                           // Records why parts of the document were dropped (see Result.Log)
+	isDestructive                     // Intermediate values will be discarded (internal)
 )
 
-func extractTextEx(root *html.Node, flags Flags) (simplified, flattened, cleaned *element) {
-	simplified = simplify(root, 0)
-	if simplified == nil {
-		return nil, nil, nil
+func extractTextEx(r *Result, opts *Options, log *explainLog) {
+	r.simplified, r.Truncated = simplify(r.roots, opts.MaxDepth, log)
+	r.Language, r.LanguageSource = opts.Language, ""
+	if r.Language == "" {
+		r.Language, r.LanguageSource = findLanguage(r.roots, r.simplified)
+	}
+	if r.simplified == nil {
+		return
 	}
+	r.flattened, r.cleaned, r.signals = cleanTree(r.simplified, opts, profileFor(r.Language), log)
+	r.Confidence = r.signals.confidence()
+}
+
+// Runs the flatten and clean stages on a simplified tree, also returns the signals of the flattened tree used to find the type of the page
+func cleanTree(simplified *element, opts *Options, profile *languageProfile, log *explainLog) (flattened, cleaned *element, signals *pageSignals) {
+	flags := opts.Flags
+	thresholds := opts.Thresholds.withDefaults()
+	profile = thresholds.apply(profile)
 	if flags&isDestructive != 0 {
-		flattened = flatten(simplified)
+		flattened = flatten(simplified, thresholds, log)
 	} else {
 		x := simplified.Clone()
 		//println("Flatten argument:", x.DebugString())
-		flattened = flatten(x)
+		flattened = flatten(x, thresholds, log)
 	}
+	signals = collectSignals(flattened, profile)
 	if flags&isDestructive != 0 {
-		cleaned = clean(flattened)
+		cleaned = clean(flattened, opts, profile, log)
 	} else {
-		cleaned = clean(flattened.Clone())
+		cleaned = clean(flattened.Clone(), opts, profile, log)
 	}
 	return
 }
 
-func simplify(node *html.Node, depth int) *element {
-	if depth > _MAX_PROCESSING_DEPTH {
-		return nil
+// State of the simplification of an element node whose children are being processed
+type simplifyFrame struct {
+	node   *html.Node
+	kind   nodeKind
+	depth  int
+	next   *html.Node   // next child to process
+	list   []*html.Node // children to process, used instead of next for a list of unrelated nodes
+	childs []*element
+}
+
+func (frame *simplifyFrame) nextChild() *html.Node {
+	if frame.list != nil {
+		if len(frame.list) == 0 {
+			return nil
+		}
+		r := frame.list[0]
+		frame.list = frame.list[1:]
+		return r
+	}
+	r := frame.next
+	if r != nil {
+		frame.next = r.NextSibling
+	}
+	return r
+}
+
+// Converts the trees rooted at roots into a tree of elements, multiple roots are treated as the children of a div element.
+// The trees are visited without recursion, if maxDepth is greater than zero nodes deeper than maxDepth are dropped and truncated is set.
+func simplify(roots []*html.Node, maxDepth int, log *explainLog) (r *element, truncated bool) {
+	var bottom *simplifyFrame
+	if len(roots) == 1 {
+		r, kind, expand := simplifyStart(roots[0], log)
+		if !expand {
+			return r, false
+		}
+		bottom = &simplifyFrame{node: roots[0], kind: kind, next: roots[0].FirstChild}
+	} else {
+		bottom = &simplifyFrame{node: &html.Node{Type: html.ElementNode, Data: "div"}, kind: _K_CONTAINER, list: roots}
 	}
 
+	stack := []*simplifyFrame{bottom}
+	for {
+		top := stack[len(stack)-1]
+
+		if childn := top.nextChild(); childn != nil {
+			if childn.Type == html.TextNode {
+				top.childs = pushText(top.childs, childn)
+				continue
+			}
+			if maxDepth > 0 && top.depth+1 > maxDepth {
+				log.addNode(childn, "depth limit (%d) exceeded", maxDepth)
+				truncated = true
+				continue
+			}
+			child, kind, expand := simplifyStart(childn, log)
+			if expand {
+				stack = append(stack, &simplifyFrame{node: childn, kind: kind, depth: top.depth + 1, next: childn.FirstChild})
+			} else if child != nil {
+				top.childs = pushElement(top.childs, child)
+			}
+			continue
+		}
+
+		stack = stack[:len(stack)-1]
+		child := simplifyEnd(top.node, top.kind, top.childs)
+		if len(stack) == 0 {
+			return child, truncated
+		}
+		if child != nil {
+			parent := stack[len(stack)-1]
+			parent.childs = pushElement(parent.childs, child)
+		}
+	}
+}
+
+// Simplifies node if it doesn't need its children to be processed, otherwise returns its kind and sets expand
+func simplifyStart(node *html.Node, log *explainLog) (r *element, kind nodeKind, expand bool) {
 	switch node.Type {
 	case html.ErrorNode:
-		return nil
+		return nil, 0, false
 	case html.CommentNode:
-		return nil
+		return nil, 0, false
 	case html.DoctypeNode:
-		return nil
+		return nil, 0, false
 	case html.DocumentNode:
-		return nil
+		return nil, 0, false
 
 	case html.TextNode:
-		return newContentElement("~text", node.Data)
+		if childs := pushText(nil, node); len(childs) > 0 {
+			return childs[0], 0, false
+		}
+		return nil, 0, false
 
 	case html.ElementNode:
 		// rest
 	}
 
-	kind := getNodeKind(node)
+	kind = getNodeKind(node)
 	if kind == _K_SUPPRESSED {
-		return nil
+		log.addNode(node, "suppressed tag")
+		return nil, kind, false
 	}
-
-	childs := []*element{}
-
-	for childn := node.FirstChild; childn != nil; childn = childn.NextSibling {
-		if childn.Type == html.TextNode {
-			childs = pushText(childs, childn)
-		} else {
-			child := simplify(childn, depth+1)
-			if child != nil {
-				childs = pushElement(childs, child)
-			}
+	if kind == _K_FIGURE {
+		if r := simplifyFigure(node); r != nil {
+			return r, kind, false
+		}
+		kind = _K_TODESTRUCTURE
+	}
+	if kind == _K_MEDIA || socialEmbedProvider(node) != "" {
+		if r := simplifyMedia(node); r != nil {
+			return r, kind, false
+		}
+		switch {
+		case strings.ToLower(node.Data) == "iframe":
+			kind = _K_TODESTRUCTURE
+		case kind == _K_MEDIA:
+			log.addNode(node, "media without a source")
+			return nil, kind, false
 		}
 	}
 
+	return nil, kind, true
+}
+
+// Simplifies an element node of the specified kind once its children have been simplified
+func simplifyEnd(node *html.Node, kind nodeKind, childs []*element) *element {
 	if len(childs) == 0 {
 		return nil
 	}
 
-	kot := false
 	switch kind {
 	case _K_KOTCONTAINER:
-		kot = true
-		fallthrough
+		if isTextOnly(childs) {
+			var r *element
+			if len(childs) == 1 {
+				r = childs[0]
+				r.tag = "~textdiv"
+			} else {
+				r = fuseText(childs)
+			}
+			r.originalTag = strings.ToLower(node.Data)
+			r.id = findId(node)
+			return r
+		}
+		if len(childs) == 1 {
+			return childs[0]
+		}
+
 	case _K_CONTAINER:
 		if len(childs) == 1 {
 			if childs[0].tag == "~text" {
-				if kot {
-					childs[0].originalTag = "h"
-				}
 				childs[0].tag = "~textdiv"
 			}
 			return childs[0]
@@ -119,28 +230,16 @@ func simplify(node *html.Node, depth int) *element {
 
 	case _K_TODESTRUCTURE:
 		if strings.ToLower(node.Data) == "tr" {
-			linkPart := float32(0.0)
 			hasComplexTds := false
-			trText := bytes.NewBuffer([]byte{})
-
 			for _, child := range childs {
 				if !(child.tag == "~textdiv") {
 					hasComplexTds = true
 					break
 				}
-
-				trText.Write([]byte(child.content))
-				trText.Write([]byte{' '})
-				linkPart += float32(len(child.content)) * child.linkPart
 			}
 
 			if !hasComplexTds {
-				r := newContentElement("~textdiv", string(trText.Bytes()))
-				r.linkPart = linkPart / float32(len(r.content))
-				for _, child := range childs {
-					r.hrefs = append(r.hrefs, child.hrefs...)
-				}
-				return r
+				return fuseText(childs)
 			}
 		}
 
@@ -153,75 +252,235 @@ func simplify(node *html.Node, depth int) *element {
 	return newChildElement(strings.ToLower(node.Data), childs)
 }
 
-func flatten(e *element) *element {
-	if e == nil {
+// Returns true if all elements of childs are text elements
+func isTextOnly(childs []*element) bool {
+	for _, child := range childs {
+		if child.tag != "~text" && child.tag != "~textdiv" {
+			return false
+		}
+	}
+	return true
+}
+
+// Fuses a list of text elements into a single ~textdiv element
+func fuseText(childs []*element) *element {
+	linkPart := float32(0.0)
+	text := bytes.NewBuffer([]byte{})
+	r := newContentElement("~textdiv", "")
+
+	for _, child := range childs {
+		text.Write([]byte(child.content))
+		text.Write([]byte{' '})
+		linkPart += float32(textLength(child.content)) * child.linkPart
+		r.hrefs = append(r.hrefs, child.hrefs...)
+		r.sources = append(r.sources, child.sources...)
+	}
+
+	r.content = string(text.Bytes())
+	r.linkPart = linkPart / float32(textLength(r.content))
+	return r
+}
+
+// Converts the tree rooted at e into a list of blocks, the tree is visited without recursion
+func flatten(e *element, t *Thresholds, log *explainLog) *element {
+	if e == nil || flattenBlock(e, t, log) {
 		return e
 	}
 
+	// blocks are appended to out in document order, each container keeps the index of its first block
+	type frame struct {
+		e     *element
+		i     int
+		start int
+	}
+
+	out := make([]*element, 0, len(e.childs))
+	stack := []*frame{{e: e}}
+	for {
+		top := stack[len(stack)-1]
+
+		if top.i < len(top.e.childs) {
+			child := top.e.childs[top.i]
+			top.i++
+			if flattenBlock(child, t, log) {
+				out = appendFlattened(out, child)
+			} else {
+				stack = append(stack, &frame{e: child, start: len(out)})
+			}
+			continue
+		}
+
+		stack = stack[:len(stack)-1]
+
+		childs := out[top.start:len(out):len(out)]
+		if top.e.tag == "table" {
+			attachCaption(childs)
+		}
+
+		top.e.tag = "~transient"
+		top.e.childs = childs
+		top.e.collapse = true
+
+		if len(stack) == 0 {
+			return e
+		}
+	}
+}
+
+// Classifies e if it is a block, returns false if it is a container that must be flattened
+func flattenBlock(e *element, t *Thresholds, log *explainLog) bool {
+	if e.tag == "~figure" || e.tag == "~media" {
+		return true
+	}
+
 	if e.isHeader() {
 		e.tag = "~header"
-		return e
+		return true
+	}
+
+	if e.isCaption() {
+		e.tag = "~caption"
+		return true
 	}
 
-	if e.isLinkList() {
+	if e.isLinkList(t) {
 		e.tag = "~linklist"
-		return e
+		log.addBlock("flatten", e, false, "list of %d items, mostly links", len(e.childs))
+		return true
 	}
 
-	if e.isLinkBlob() {
+	if e.isLinkBlob(t) {
 		e.tag = "~linkblob"
-		return e
+		log.addBlock("flatten", e, false, "link blob with linkPart %.2f", e.linkPart)
+		return true
 	}
 
 	if e.childs == nil {
 		e.tag = "~textblock"
-		return e
+		return true
 	}
 
-	childs := make([]*element, 0, len(e.childs))
+	return false
+}
 
-	for i := range e.childs {
-		fchild := flatten(e.childs[i])
-		if fchild.collapse {
-			for _, subchild := range fchild.childs {
-				childs = append(childs, subchild)
-			}
-		} else {
-			childs = append(childs, fchild)
-		}
+// Appends a flattened element to childs
+func appendFlattened(childs []*element, fchild *element) []*element {
+	if fchild.collapse {
+		return append(childs, fchild.childs...)
 	}
+	return append(childs, fchild)
+}
 
-	e.tag = "~transient"
-	e.childs = childs
-	e.collapse = true
-	return e
+// Moves the caption of a table in front of its contents and records how many elements it captions
+func attachCaption(childs []*element) {
+	for i := range childs {
+		if childs[i].tag == "~caption" {
+			caption := childs[i]
+			copy(childs[1:i+1], childs[:i])
+			childs[0] = caption
+			caption.span = len(childs) - 1
+			return
+		}
+	}
 }
 
-func clean(e *element) *element {
-	if e == nil || e.childs == nil {
+func clean(e *element, opts *Options, profile *languageProfile, log *explainLog) *element {
+	if e == nil {
 		return e
 	}
+	if e.childs == nil {
+		// a tree made of a single block is cleaned like a document containing only that block
+		return clean(&element{tag: "~transient", childs: []*element{e}}, opts, profile, log).childs[0]
+	}
+
+	blocks := make([]*element, len(e.childs))
+	copy(blocks, e.childs)
+	s := &BlockSet{parent: e, blocks: blocks, flags: opts.Flags, profile: profile, reasons: make([]string, len(e.childs)), log: log}
+
+	s.actions = runHook(opts.Hook, e.childs)
+	for i := range e.childs {
+		if s.actions[i] == DropBlock {
+			s.Drop(i, "dropped by hook")
+		}
+	}
 
 	for i := range e.childs {
-		if e.childs[i] == nil {
+		if s.Decided(i) {
 			continue
 		}
 
 		switch e.childs[i].tag {
 		case "~linkblob":
-			fallthrough
+			s.Drop(i, "link blob with linkPart %.2f", e.childs[i].linkPart)
 		case "~linklist":
-			e.childs[i] = nil
+			s.Drop(i, "link list")
+		}
+	}
+
+	extractor := opts.Extractor
+	if extractor == nil {
+		extractor = DefaultExtractor
+	}
+	extractor.Classify(s)
+
+	for i := range e.childs {
+		if s.Decided(i) || e.childs[i].span <= 0 {
+			continue
+		}
+		keep := false
+		for j := i + 1; j <= i+e.childs[i].span && j < len(e.childs); j++ {
+			if e.childs[j] != nil {
+				keep = true
+				break
+			}
+		}
+		if !keep {
+			s.Drop(i, "caption of a dropped table")
+		}
+	}
+
+	if log != nil {
+		for i, block := range blocks {
+			switch {
+			case e.childs[i] == nil:
+				log.addBlock("clean", block, true, "%s", s.reasons[i])
+			case s.actions[i] == KeepBlock:
+				log.addBlock("clean", block, false, "kept by hook")
+			case s.reasons[i] != "":
+				log.addBlock("clean", block, false, "%s", s.reasons[i])
+			case profile.okText(block):
+				log.addBlock("clean", block, false, "ok text")
+			case block.span > 0:
+				log.addBlock("clean", block, false, "caption of a kept table")
+			default:
+				log.addBlock("clean", block, false, "next to ok text")
+			}
+		}
+	}
+
+	return e
+}
+
+// Drops the blocks that are too short or far from good text
+func cleanRules(s *BlockSet) {
+	e, profile := s.parent, s.profile
+	for i := range e.childs {
+		if s.Decided(i) {
+			continue
+		}
 
+		switch e.childs[i].tag {
 		case "~textblock":
-			if len(e.childs[i].content) <= 15 || strings.Index(e.childs[i].content, " ") < 0 {
-				e.childs[i] = nil
+			if textLength(e.childs[i].content) <= profile.shortLength {
+				s.Drop(i, "textblock shorter than %d characters", profile.shortLength)
+			} else if !hasWordBreaks(e.childs[i].content) {
+				s.Drop(i, "textblock without word breaks")
 			}
 		}
 	}
 
 	for i := range e.childs {
-		if e.childs[i] == nil {
+		if s.Decided(i) {
 			continue
 		}
 
@@ -235,18 +494,21 @@ func clean(e *element) *element {
 			prev = e.childs[i-1]
 		}
 
+		if e.childs[i].span > 0 {
+			// table captions are decided after their table
+			continue
+		}
+
 		if e.tag == "~header" {
-			if !next.okText() {
-				e.childs[i] = nil
+			if !profile.okText(next) {
+				s.Drop(i, "header not followed by ok text")
 			}
-		} else if !e.childs[i].okText() {
-			if !next.okText() && !prev.okText() {
-				e.childs[i] = nil
+		} else if !profile.okText(e.childs[i]) {
+			if !profile.okText(next) && !profile.okText(prev) {
+				s.Drop(i, "isolated block that isn't ok text")
 			}
 		}
 	}
-
-	return e
 }
 
 func makeIndent(depth int) string {
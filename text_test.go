@@ -0,0 +1,210 @@
+package sandblast
+
+import (
+	"errors"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"strings"
+	"testing"
+)
+
+func TestDeepDocument(t *testing.T) {
+	const depth = 20000
+	const par = "This is a paragraph buried deep inside the document, long enough to be kept."
+
+	// built by hand, html.Parse limits nesting to 512 elements
+	node, err := html.Parse(strings.NewReader("<html><body></body></html>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent := findElement(node, "body")
+	for i := 0; i < depth; i++ {
+		span := &html.Node{Type: html.ElementNode, Data: "span"}
+		span.AppendChild(&html.Node{Type: html.TextNode, Data: "x"})
+		div := &html.Node{Type: html.ElementNode, Data: "div"}
+		div.AppendChild(span)
+		parent.AppendChild(div)
+		parent = div
+	}
+	for i := 0; i < 2; i++ {
+		p := &html.Node{Type: html.ElementNode, Data: "p"}
+		p.AppendChild(&html.Node{Type: html.TextNode, Data: par})
+		parent.AppendChild(p)
+	}
+
+	r, err := ExtractResult(node, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Truncated || strings.Count(r.Text, par) != 2 {
+		t.Errorf("Deep paragraphs lost (truncated: %v)", r.Truncated)
+	}
+
+	r, err = ExtractWithOptions(node, &Options{Flags: Explain, MaxDepth: 100})
+	var derr *DepthError
+	if !errors.Is(err, ErrDepthExceeded) || !errors.As(err, &derr) || derr.MaxDepth != 100 {
+		t.Errorf("Wrong error for truncated document: %v", err)
+	}
+	if !r.Truncated || strings.Index(r.Text, par) >= 0 {
+		t.Errorf("Document not truncated (truncated: %v)", r.Truncated)
+	}
+	found := false
+	for _, d := range r.Log {
+		if d.Reason == "depth limit (100) exceeded" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Truncation not recorded")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tf := func(doc string, target error) {
+		node, err := html.Parse(strings.NewReader(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = ExtractResult(node, 0)
+		if !errors.Is(err, target) {
+			t.Errorf("Wrong error for <%s>\n\tgot <%v>\n\texpected <%v>\n", doc, err, target)
+		}
+		_, _, _, _, cleaned, err := ExtractEx(node, 0)
+		if !errors.Is(err, target) || cleaned == nil {
+			t.Errorf("Wrong error from ExtractEx for <%s>\n\tgot <%v>\n\texpected <%v>\n", doc, err, target)
+		}
+		_, _, err = Extract(node, 0)
+		if !errors.Is(err, target) {
+			t.Errorf("Wrong error from Extract for <%s>\n\tgot <%v>\n\texpected <%v>\n", doc, err, target)
+		}
+	}
+	tf("<p>Hello</p>", ErrEmptyContent)
+	tf("<p>This is a paragraph long enough to be extracted, it should not fail.</p>", nil)
+
+	if _, err := ExtractResult(nil, 0); !errors.Is(err, ErrNoRoot) {
+		t.Errorf("Wrong error for nil document: %v", err)
+	}
+	if _, err := ExtractResult(&html.Node{Type: html.TextNode, Data: "text"}, 0); !errors.Is(err, ErrNotHTML) {
+		t.Errorf("Wrong error for text node: %v", err)
+	}
+	if _, err := ExtractResult(&html.Node{Type: html.DocumentNode}, 0); !errors.Is(err, ErrNoRoot) {
+		t.Errorf("Wrong error for empty document: %v", err)
+	}
+
+	partial := map[error]bool{ErrEmptyContent: true, &DepthError{10}: true, ErrNoRoot: false, ErrNotHTML: false}
+	for err, target := range partial {
+		if IsPartialError(err) != target {
+			t.Errorf("Wrong partial error check for <%v>\n\tgot <%v>\n\texpected <%v>\n", err, !target, target)
+		}
+	}
+}
+
+func TestBodyFallback(t *testing.T) {
+	const par = "This is a paragraph long enough to be extracted from a document without html."
+	doc := &html.Node{Type: html.DocumentNode}
+	body := &html.Node{Type: html.ElementNode, Data: "body"}
+	p := &html.Node{Type: html.ElementNode, Data: "p"}
+	p.AppendChild(&html.Node{Type: html.TextNode, Data: par})
+	body.AppendChild(p)
+	doc.AppendChild(body)
+
+	r, err := ExtractResult(doc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(r.Text) != par {
+		t.Errorf("Wrong text extracted from body: %q", r.Text)
+	}
+
+	svg := &html.Node{Type: html.DocumentNode}
+	svg.AppendChild(&html.Node{Type: html.ElementNode, Data: "svg"})
+	if _, err := ExtractResult(svg, 0); !errors.Is(err, ErrNotHTML) {
+		t.Errorf("Wrong error for svg document: %v", err)
+	}
+}
+
+func TestNilOptions(t *testing.T) {
+	const par = "This is a paragraph long enough to be extracted with the default options."
+	node, err := html.Parse(strings.NewReader("<html><body><p>" + par + "</p></body></html>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ExtractWithOptions(node, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(r.Text) != par {
+		t.Errorf("Wrong text extracted with nil options: %q", r.Text)
+	}
+}
+
+func TestExtractNode(t *testing.T) {
+	const par = "This is a paragraph long enough to be extracted from an HTML snippet."
+
+	nodes, err := html.ParseFragment(strings.NewReader("<p>"+par+"</p>Some loose text, long enough to be kept next to the paragraph.<ul><li><a href='/'>x</a></li></ul>"), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ExtractNodes(nodes, &Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Paragraphs) != 2 || r.Paragraphs[0].Text != par {
+		t.Errorf("Wrong paragraphs extracted from fragment: %q", r.Text)
+	}
+
+	node, err := html.Parse(strings.NewReader("<html><body><div id='nav'>Navigation text that should not be extracted because it is outside.</div><div id='content'><p>" + par + "</p></div></body></html>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content *html.Node
+	forEachElement(node, "div", func(div *html.Node) {
+		if getAttribute(div, "id") == "content" {
+			content = div
+		}
+	})
+	r, err = ExtractNode(content, &Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(r.Text) != par {
+		t.Errorf("Wrong text extracted from node: %q", r.Text)
+	}
+
+	r, err = ExtractNode(content, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(r.Text) != par {
+		t.Errorf("Wrong text extracted from node with nil options: %q", r.Text)
+	}
+	if _, err := ExtractNodes(nodes, nil); err != nil {
+		t.Errorf("Error extracting fragment with nil options: %v", err)
+	}
+
+	// a text node is simplified and cleaned like the text of any other element
+	r, err = ExtractNode(&html.Node{Type: html.TextNode, Data: "  A text node  long\n\n enough to be kept as the text of the document.  "}, nil)
+	if err != nil || strings.TrimSpace(r.Text) != "A text node long enough to be kept as the text of the document." {
+		t.Errorf("Wrong text extracted from text node: %q (%v)", r.Text, err)
+	}
+	if r, err := ExtractNode(&html.Node{Type: html.TextNode, Data: "Hi"}, nil); !errors.Is(err, ErrEmptyContent) {
+		t.Errorf("Short text node not cleaned: %q (%v)", r.Text, err)
+	}
+}
+
+func TestUnspacedScripts(t *testing.T) {
+	tf := func(par string, kept bool) {
+		doc, err := html.Parse(strings.NewReader("<html><body><p>" + par + "</p></body></html>"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, _ := ExtractResult(doc, 0)
+		if out := strings.TrimSpace(r.Text) == par; out != kept {
+			t.Errorf("Error extracting <%s>\n\tgot <%v>\n\texpected <%v>\n", par, out, kept)
+		}
+	}
+	tf("東京都は十九日、新しい交通計画を発表した。計画には鉄道の延伸と新しいバス路線の整備が含まれており、二〇三〇年までに完成する予定だ。", true)
+	tf("กรุงเทพมหานครประกาศแผนการคมนาคมใหม่ซึ่งรวมถึงการขยายเส้นทางรถไฟฟ้าและการปรับปรุงเส้นทางรถประจำทางทั่วเมือง", true)
+	tf("東京都は発表した。", false)
+	tf("http://example.com/a/very/long/address/without/any/spaces/at/all/in/it/whatsoever", false)
+}
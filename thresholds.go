@@ -0,0 +1,59 @@
+package sandblast
+
+// Thresholds of the rules of the flatten stage and of the default extractor, zero fields take their default value
+type Thresholds struct {
+	LinkBlob      float64 // text with a larger fraction of its characters inside links is a link blob (default 0.7)
+	LinkListItem  float64 // items of a list with a larger fraction of their characters inside links are links (default 0.7)
+	LinkListRatio float64 // lists where more than this fraction of the items are links are link lists (default 0.75)
+	LinkListSize  int     // minimum number of items of a link list (default 5)
+	ShortLength   int     // textblocks up to this length in characters are dropped (default from the language, 15 for English)
+	OkLength      int     // textblocks longer than this in characters are ok text (default from the language, 50 for English)
+}
+
+// Default values of the thresholds, ShortLength and OkLength depend on the language of the document
+var DefaultThresholds = Thresholds{LinkBlob: 0.7, LinkListItem: 0.7, LinkListRatio: 0.75, LinkListSize: 5}
+
+// Returns the default thresholds with the lengths of the profile of a language (an ISO 639-1 code, "" for the default profile)
+func ThresholdsFor(lang string) Thresholds {
+	r := DefaultThresholds
+	profile := profileFor(normalizeLanguage(lang))
+	r.ShortLength, r.OkLength = profile.shortLength, profile.okLength
+	return r
+}
+
+// Returns a copy of t where zero fields have their default value, t can be nil
+func (t *Thresholds) withDefaults() *Thresholds {
+	r := DefaultThresholds
+	if t == nil {
+		return &r
+	}
+	if t.LinkBlob != 0 {
+		r.LinkBlob = t.LinkBlob
+	}
+	if t.LinkListItem != 0 {
+		r.LinkListItem = t.LinkListItem
+	}
+	if t.LinkListRatio != 0 {
+		r.LinkListRatio = t.LinkListRatio
+	}
+	if t.LinkListSize != 0 {
+		r.LinkListSize = t.LinkListSize
+	}
+	r.ShortLength, r.OkLength = t.ShortLength, t.OkLength
+	return &r
+}
+
+// Returns profile with the lengths overridden by t
+func (t *Thresholds) apply(profile *languageProfile) *languageProfile {
+	if t == nil || (t.ShortLength == 0 && t.OkLength == 0) {
+		return profile
+	}
+	r := *profile
+	if t.ShortLength != 0 {
+		r.shortLength = t.ShortLength
+	}
+	if t.OkLength != 0 {
+		r.okLength = t.OkLength
+	}
+	return &r
+}
@@ -0,0 +1,35 @@
+package sandblast
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestThresholds(t *testing.T) {
+	const par = "This is the first paragraph of the article, it is long enough to be kept."
+	const links = "<ul><li><a href='/a'>First</a> other story</li><li><a href='/b'>Second</a> other story</li><li><a href='/c'>Third</a> other story</li></ul>"
+	tf := func(doc string, th *Thresholds, target string) {
+		node, err := html.Parse(strings.NewReader(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, _ := ExtractWithOptions(node, &Options{Thresholds: th})
+		if out := strings.TrimSpace(string(collapseWhitespace([]rune(r.Text)))); out != target {
+			t.Errorf("Error extracting <%s> with %+v\n\tgot <%s>\n\texpected <%s>\n", doc, th, out, target)
+		}
+	}
+	doc := "<html><body><p>" + par + "</p>" + links + "</body></html>"
+	tf(doc, nil, par+" First other story")
+	tf(doc, &Thresholds{LinkListSize: 3}, par+" First other story")
+	tf(doc, &Thresholds{LinkListSize: 3, LinkListItem: 0.2}, par)
+	tf(doc, &Thresholds{OkLength: 100}, "")
+	tf("<html><body><p>"+par+"</p><p>Short but kept</p></body></html>", &Thresholds{ShortLength: 5}, par+" Short but kept")
+
+	for lang, target := range map[string][2]int{"": {15, 50}, "en": {15, 50}, "de-AT": {18, 60}, "ja": {8, 25}} {
+		th := ThresholdsFor(lang)
+		if th.ShortLength != target[0] || th.OkLength != target[1] || th.LinkBlob != DefaultThresholds.LinkBlob {
+			t.Errorf("Wrong thresholds for language %q: %+v", lang, th)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package sandblast
+
+import (
+	"golang.org/x/net/html"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// Separators between the headline and the name of the site (or section) in titles, from the strongest to the weakest
+var titleSeparators = []string{" | ", " · ", " • ", " :: ", " » ", " « ", " — ", " – ", " - ", " / "}
+
+// Chooses the title of the document among the contents of the title element, the og:title meta property and the first h1 heading
+// of the extracted content, returns the title and its source ("title", "og:title", "h1" or "" if no title was found).
+// The h1 heading is only chosen if it is the headline of the title element or of og:title.
+func findTitle(roots []*html.Node, cleaned *element) (title, source string) {
+	var raw, og string
+	var siteNames []string
+	for _, root := range roots {
+		if raw == "" {
+			raw = getTitle(root)
+		}
+		if og == "" {
+			og = getMeta(root, "og:title", "twitter:title")
+		}
+		siteNames = appendSiteNames(siteNames, root)
+	}
+	h1 := firstHeading(cleaned)
+
+	switch {
+	case h1 != "" && (sameTitle(cleanTitle(raw, siteNames), h1) || sameTitle(og, h1) || sameTitle(cleanTitle(og, siteNames), h1)):
+		return h1, "h1"
+	case og != "" && containsTitle(raw, og):
+		return og, "og:title"
+	case raw != "":
+		return cleanTitle(raw, siteNames), "title"
+	case og != "":
+		return cleanTitle(og, siteNames), "og:title"
+	case h1 != "":
+		return h1, "h1"
+	}
+	return "", ""
+}
+
+// Returns the headline of a title, without the name of the site (or section).
+// The segments at the end of the title that are one of siteNames (the names and the host names the site is known by) are removed,
+// if there aren't any the headline is the segment before the strongest separator of the title,
+// or the segment after it when that one is more than twice as long (for titles starting with the name of the site).
+func cleanTitle(title string, siteNames []string) string {
+	stripped := false
+	for {
+		i, n := -1, 0
+		for _, sep := range titleSeparators {
+			if k := strings.LastIndex(title, sep); k > i {
+				i, n = k, len(sep)
+			}
+		}
+		if i < 0 || !isSiteName(title[i+n:], siteNames) {
+			break
+		}
+		title, stripped = title[:i], true
+	}
+	title = strings.TrimSpace(title)
+	if stripped {
+		return title
+	}
+
+	for _, sep := range titleSeparators {
+		if i := strings.Index(title, sep); i >= 0 {
+			head := strings.TrimSpace(title[:i])
+			tail := strings.TrimSpace(title[strings.LastIndex(title, sep)+len(sep):])
+			if len(splitWords(tail)) > 2*len(splitWords(head)) {
+				return tail
+			}
+			return head
+		}
+	}
+	return title
+}
+
+// Appends the names and the host name of the site declared by the meta elements and the canonical link of root
+func appendSiteNames(siteNames []string, root *html.Node) []string {
+	if name := getMeta(root, "og:site_name", "application-name"); name != "" {
+		siteNames = append(siteNames, name)
+	}
+	urls := []string{getMeta(root, "og:url")}
+	forEachElement(root, "link", func(link *html.Node) {
+		if strings.EqualFold(strings.TrimSpace(getAttribute(link, "rel")), "canonical") {
+			urls = append(urls, getAttribute(link, "href"))
+		}
+	})
+	for _, rawurl := range urls {
+		if u, err := url.Parse(strings.TrimSpace(rawurl)); err == nil && u.Hostname() != "" {
+			siteNames = append(siteNames, strings.ToLower(u.Hostname()))
+		}
+	}
+	return siteNames
+}
+
+// Returns true if segment of a title is one of siteNames, a host name matches the segment if one of its labels
+// (other than the top level domain) is the segment or the first word of the segment
+func isSiteName(segment string, siteNames []string) bool {
+	compact := func(s string) string {
+		return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(ch rune) bool {
+			return !unicode.IsLetter(ch) && !unicode.IsDigit(ch)
+		}), "")
+	}
+	fields := strings.Fields(segment)
+	if len(fields) == 0 {
+		return false
+	}
+	whole, first := compact(segment), compact(fields[0])
+	for _, name := range siteNames {
+		if !strings.Contains(name, ".") || strings.Contains(name, " ") {
+			if compact(name) == whole {
+				return true
+			}
+			continue
+		}
+		labels := strings.Split(strings.TrimPrefix(name, "www."), ".")
+		if compact(strings.Join(labels, "")) == whole {
+			return true
+		}
+		for _, label := range labels[:len(labels)-1] {
+			if label != "" && (label == whole || label == first) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Returns true if title contains headline, ignoring case, spacing and punctuation
+func containsTitle(title, headline string) bool {
+	headline = normalizeTitle(headline)
+	return headline != "" && strings.Index(normalizeTitle(title), headline) >= 0
+}
+
+// Returns true if title and headline are the same, ignoring case, spacing and punctuation
+func sameTitle(title, headline string) bool {
+	headline = normalizeTitle(headline)
+	return headline != "" && normalizeTitle(title) == headline
+}
+
+func normalizeTitle(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(ch rune) bool {
+		return !unicode.IsLetter(ch) && !unicode.IsDigit(ch)
+	}), " ")
+}
+
+// Returns the text of the first h1 heading of a cleaned tree
+func firstHeading(cleaned *element) string {
+	if cleaned == nil {
+		return ""
+	}
+	for _, block := range cleaned.childs {
+		if block != nil && block.tag == "~header" && block.headingLevel() == 1 {
+			return blockText(block)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package sandblast
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestCleanTitle(t *testing.T) {
+	tf := func(in string, siteNames []string, target string) {
+		out := cleanTitle(in, siteNames)
+		if out != target {
+			t.Errorf("Error cleaning title <%s>\n\tgot <%s>\n\texpected <%s>\n", in, out, target)
+		}
+	}
+	site := []string{"Site Name", "www.example.com"}
+	tf("Article headline | Site Name", site, "Article headline")
+	tf("Article headline - Section | Site Name", site, "Article headline - Section")
+	tf("Article headline | Site Name - Section", site, "Article headline")
+	tf("Article headline | Site Name - Section", nil, "Article headline")
+	tf("Article headline — Example.com", site, "Article headline")
+	tf("Article headline | Example News", site, "Article headline")
+	tf("Site » A much longer article headline", site, "A much longer article headline")
+	tf("Covid - the aftermath | BBC News", []string{"BBC News"}, "Covid - the aftermath")
+	tf("Covid - the aftermath | BBC News", []string{"www.bbc.co.uk"}, "Covid - the aftermath")
+	tf("Covid - the aftermath | BBC News", nil, "Covid - the aftermath")
+	tf("Headline: with a colon", site, "Headline: with a colon")
+	tf("Self-driving cars are here", site, "Self-driving cars are here")
+}
+
+func TestFindTitle(t *testing.T) {
+	tf := func(doc, target, targetSource string) {
+		node, err := html.Parse(strings.NewReader(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, _ := ExtractResult(node, 0)
+		if r.Title != target || r.TitleSource != targetSource {
+			t.Errorf("Error finding title of <%s>\n\tgot <%s> (%s)\n\texpected <%s> (%s)\n", doc, r.Title, r.TitleSource, target, targetSource)
+		}
+	}
+	const par = "<p>This is a paragraph long enough to be kept as the text of the article.</p>"
+	tf("<html><head><title>Big News Today | The Daily - World</title></head><body><h1>Big news today</h1>"+par+"</body></html>", "Big news today", "h1")
+	tf("<html><head><title>Big News Today | The Daily - World</title><meta property='og:title' content='Big News Today'></head><body>"+par+"</body></html>", "Big News Today", "og:title")
+	tf("<html><head><title>Big News Today | The Daily</title><meta property='og:site_name' content='The Daily'></head><body>"+par+"</body></html>", "Big News Today", "title")
+	tf("<html><head><title>Big News Today | The Daily</title><link rel='canonical' href='https://www.thedaily.com/news/1'></head><body>"+par+"</body></html>", "Big News Today", "title")
+	tf("<html><head><title>Big News Today | The Daily - World</title></head><body>"+par+"</body></html>", "Big News Today", "title")
+	tf("<html><head><title>Big News Today | The Daily</title></head><body><h1><a href='/'>The Daily</a></h1>"+par+"</body></html>", "Big News Today", "title")
+	tf("<html><head><title>Big News Today, and more | The Daily</title></head><body><h1>Big News Today</h1>"+par+"</body></html>", "Big News Today, and more", "title")
+	tf("<html><body><svg><title>icon</title></svg><title>Misplaced title</title>"+par+"</body></html>", "Misplaced title", "title")
+	tf("<html><body><h1>Only a heading</h1>"+par+"</body></html>", "Only a heading", "h1")
+	tf("<html><body>"+par+"</body></html>", "", "")
+}
@@ -0,0 +1,97 @@
+package sandblast
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// An entry of the table of contents of the extracted text
+type TOCEntry struct {
+	Level   int         // Level of the heading (1 to 6)
+	Title   string      // Text of the heading
+	ID      string      // Value of the id attribute of the heading in the original document or, if it didn't have one, a unique id generated from its text
+	Entries []*TOCEntry // Entries for the subsections
+}
+
+// Builds the table of contents of the extracted text from its outline
+func buildTOC(outline *Section) []*TOCEntry {
+	if outline == nil || len(outline.Sections) == 0 {
+		return nil
+	}
+	r := make([]*TOCEntry, 0, len(outline.Sections))
+	for _, s := range outline.Sections {
+		r = append(r, &TOCEntry{Level: s.Level, Title: s.Heading, ID: s.ID, Entries: buildTOC(s)})
+	}
+	return r
+}
+
+// Assigns unique ids to headings
+type idSet map[string]bool
+
+func newIdSet(blocks []*element) idSet {
+	ids := idSet{}
+	for _, block := range blocks {
+		if block != nil && block.id != "" {
+			ids[block.id] = true
+		}
+	}
+	return ids
+}
+
+// Returns the ids of the headings of a cleaned tree, shared by the outline and the paragraphs
+func headingIds(cleaned *element) map[*element]string {
+	r := map[*element]string{}
+	if cleaned == nil {
+		return r
+	}
+	blocks := cleaned.childs
+	if blocks == nil {
+		blocks = []*element{cleaned}
+	}
+	ids := newIdSet(blocks)
+	for _, block := range blocks {
+		if block == nil || block.tag != "~header" {
+			continue
+		}
+		if text := blockText(block); text != "" {
+			r[block] = ids.get(block, text)
+		}
+	}
+	return r
+}
+
+// Returns the original id of the heading block, generates a new one from text if it doesn't have one
+func (ids idSet) get(block *element, text string) string {
+	if block.id != "" {
+		return block.id
+	}
+	base := slugify(text)
+	if base == "" {
+		base = "section"
+	}
+	id := base
+	for i := 2; ids[id]; i++ {
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+	ids[id] = true
+	return id
+}
+
+// Converts text to a lowercase string of letters and digits separated by '-'
+func slugify(text string) string {
+	out := make([]rune, 0, len(text))
+	dash := false
+	for _, ch := range strings.ToLower(text) {
+		if unicode.IsLetter(ch) || unicode.IsDigit(ch) {
+			if dash && len(out) > 0 {
+				out = append(out, '-')
+			}
+			out = append(out, ch)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return string(out)
+}
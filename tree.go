@@ -0,0 +1,140 @@
+package sandblast
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Serializable copy of an intermediate tree of the extraction (see ExtractEx), it can be encoded with encoding/json.
+// The elements are stored in a flat list, so that trees of any depth can be encoded and decoded.
+type Tree struct {
+	Nodes []TreeNode `json:"nodes"` // Nodes[0] is the root, every other node is the child of a node that comes before it
+}
+
+// An element of a Tree
+type TreeNode struct {
+	Tag         string   `json:"tag"`
+	Childs      []int    `json:"childs,omitempty"` // indices of the children in Tree.Nodes, -1 for blocks removed by the clean stage
+	Content     string   `json:"content,omitempty"`
+	Collapse    bool     `json:"collapse,omitempty"`
+	OriginalTag string   `json:"originalTag,omitempty"`
+	LinkPart    float32  `json:"linkPart,omitempty"`
+	Hrefs       []string `json:"hrefs,omitempty"`
+	Id          string   `json:"id,omitempty"`
+	Span        int      `json:"span,omitempty"`
+	Figure      *Figure  `json:"figure,omitempty"`
+	Media       *Media   `json:"media,omitempty"`
+}
+
+// Returns a copy of the tree rooted at e, a tree without nodes if e is nil
+func NewTree(e *element) *Tree {
+	t := &Tree{}
+	if e == nil {
+		return t
+	}
+	type frame struct {
+		e      *element
+		parent int
+	}
+	stack := []frame{{e, -1}}
+	for len(stack) > 0 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if f.e == nil {
+			t.Nodes[f.parent].Childs = append(t.Nodes[f.parent].Childs, -1)
+			continue
+		}
+		i := len(t.Nodes)
+		if f.parent >= 0 {
+			t.Nodes[f.parent].Childs = append(t.Nodes[f.parent].Childs, i)
+		}
+		e := f.e
+		t.Nodes = append(t.Nodes, TreeNode{Tag: e.tag, Content: e.content, Collapse: e.collapse, OriginalTag: e.originalTag, LinkPart: e.linkPart,
+			Hrefs: e.hrefs, Id: e.id, Span: e.span, Figure: e.figure, Media: e.media})
+		for k := len(e.childs) - 1; k >= 0; k-- {
+			stack = append(stack, frame{e.childs[k], i})
+		}
+	}
+	return t
+}
+
+// Returns the elements of the tree, nil if the tree doesn't have nodes
+func (t *Tree) root() (*element, error) {
+	if len(t.Nodes) == 0 {
+		return nil, nil
+	}
+	elements := make([]*element, len(t.Nodes))
+	for i := range t.Nodes {
+		n := &t.Nodes[i]
+		if n.Tag == "" {
+			return nil, fmt.Errorf("Node %d of tree without tag", i)
+		}
+		elements[i] = &element{tag: n.Tag, content: n.Content, collapse: n.Collapse, originalTag: n.OriginalTag, linkPart: n.LinkPart,
+			hrefs: n.Hrefs, id: n.Id, span: n.Span, figure: n.Figure, media: n.Media}
+	}
+	parents := make([]bool, len(t.Nodes)) // nodes that already have a parent
+	for i := range t.Nodes {
+		for _, child := range t.Nodes[i].Childs {
+			switch {
+			case child == -1:
+				elements[i].childs = append(elements[i].childs, nil)
+				continue
+			case child <= i || child >= len(t.Nodes):
+				return nil, fmt.Errorf("Wrong child %d of node %d of tree", child, i)
+			case parents[child]:
+				return nil, fmt.Errorf("Node %d of tree has more than one parent", child)
+			}
+			parents[child] = true
+			elements[i].childs = append(elements[i].childs, elements[child])
+		}
+	}
+	for i := 1; i < len(parents); i++ {
+		if !parents[i] {
+			return nil, fmt.Errorf("Node %d of tree without parent", i)
+		}
+	}
+	return elements[0], nil
+}
+
+// Serializes the tree rooted at e as a Tree
+func (e *element) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewTree(e))
+}
+
+// Runs the flatten and clean stages of the extraction on a simplified tree, for example one decoded from JSON.
+// Nil options are the same as the zero Options. The title of the result can only be taken from the first h1 heading.
+func ExtractTree(t *Tree, opts *Options) (*Result, error) {
+	if t == nil {
+		return nil, ErrNoRoot
+	}
+	simplified, err := t.root()
+	if err != nil {
+		return nil, err
+	}
+	if simplified == nil {
+		return nil, ErrNoRoot
+	}
+
+	if opts == nil {
+		opts = &Options{}
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	o := *opts
+	o.Flags &^= isDestructive
+	var log *explainLog
+	if o.Flags&Explain != 0 {
+		log = &explainLog{}
+	}
+
+	r := &Result{simplified: simplified}
+	r.Language = o.Language
+	if r.Language == "" {
+		r.Language, r.LanguageSource = findLanguage(nil, simplified)
+	}
+	r.flattened, r.cleaned, r.signals = cleanTree(simplified, &o, profileFor(r.Language), log)
+	r.Confidence = r.signals.confidence()
+	r.build(&o, log)
+	return r, r.partialError(&o)
+}
@@ -0,0 +1,103 @@
+package sandblast
+
+import (
+	"encoding/json"
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestTreeJSON(t *testing.T) {
+	node, err := html.Parse(strings.NewReader(figureTestDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, text, simplified, flattened, cleaned, err := ExtractEx(node, KeepImages)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tree := range []*element{simplified, flattened, cleaned} {
+		data, err := json.Marshal(tree)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var loaded Tree
+		if err := json.Unmarshal(data, &loaded); err != nil {
+			t.Fatal(err)
+		}
+		root, err := loaded.root()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root.DebugString() != tree.DebugString() {
+			t.Errorf("Tree changed by serialization\n\tgot:\n%s\n\texpected:\n%s\n", root.DebugString(), tree.DebugString())
+		}
+	}
+
+	data, _ := json.Marshal(simplified)
+	var loaded Tree
+	json.Unmarshal(data, &loaded)
+	r, err := ExtractTree(&loaded, &Options{Flags: KeepImages})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Text != text {
+		t.Errorf("Different text extracting from a loaded tree\n\tgot:\n%s\n\texpected:\n%s\n", r.Text, text)
+	}
+	if data2, _ := json.Marshal(&loaded); string(data2) != string(data) {
+		t.Errorf("Tree modified by ExtractTree")
+	}
+
+	if _, err := ExtractTree(&loaded, nil); err != nil {
+		t.Errorf("Error extracting a loaded tree with nil options: %v", err)
+	}
+
+	for _, data := range []string{
+		`{"nodes":[{"content":"no tag"}]}`,
+		`{"nodes":[{"tag":"~div","childs":[1]}]}`,
+		`{"nodes":[{"tag":"~div","childs":[0]}]}`,
+		`{"nodes":[{"tag":"~div","childs":[1,1]},{"tag":"~text"}]}`,
+		`{"nodes":[{"tag":"~div"},{"tag":"~text"}]}`,
+		`{"nodes":[{"tag":"~div","childs":[2]},{"tag":"~div","childs":[1]},{"tag":"~text"}]}`,
+	} {
+		var tree Tree
+		if err := json.Unmarshal([]byte(data), &tree); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ExtractTree(&tree, nil); err == nil || err == ErrNoRoot {
+			t.Errorf("Extracted invalid tree %s (%v)", data, err)
+		}
+	}
+	if _, err := ExtractTree(&Tree{}, nil); err != ErrNoRoot {
+		t.Errorf("Wrong error extracting an empty tree: %v", err)
+	}
+}
+
+func TestDeepTreeJSON(t *testing.T) {
+	const depth = 20000
+	root := &element{tag: "~div"}
+	e := root
+	for i := 0; i < depth; i++ {
+		child := &element{tag: "~div"}
+		e.childs = []*element{nil, child}
+		e = child
+	}
+	e.childs = []*element{newContentElement("~text", "deep")}
+
+	data, err := json.Marshal(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tree Tree
+	if err := json.Unmarshal(data, &tree); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := tree.root()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data2, err := json.Marshal(loaded); err != nil || string(data2) != string(data) {
+		t.Errorf("Deep tree changed by serialization (%v)", err)
+	}
+}
@@ -123,9 +123,109 @@ func cleanControl(in []rune) []rune {
 
 func getAttribute(node *html.Node, name string) string {
 	for i := range node.Attr {
-		if strings.ToLower(node.Attr[i].Key) == "href" {
+		if strings.ToLower(node.Attr[i].Key) == name {
 			return node.Attr[i].Val
 		}
 	}
 	return ""
 }
+
+func hasAttribute(node *html.Node, name string) bool {
+	for i := range node.Attr {
+		if strings.ToLower(node.Attr[i].Key) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the id of node or, if it doesn't have one, the id (or anchor name) of its first descendant that has one
+func findId(node *html.Node) string {
+	if node.Type != html.ElementNode {
+		return ""
+	}
+	id := ownId(node)
+	walkNodes(node, func(child *html.Node) bool {
+		if id != "" || child.Type != html.ElementNode {
+			return false
+		}
+		id = ownId(child)
+		return id == ""
+	})
+	return id
+}
+
+// Returns the id of node or, for anchors, their name
+func ownId(node *html.Node) string {
+	if id := getAttribute(node, "id"); id != "" {
+		return id
+	}
+	if strings.ToLower(node.Data) == "a" {
+		return getAttribute(node, "name")
+	}
+	return ""
+}
+
+// Returns node if it has the specified tag name, otherwise its first descendant with that tag name
+func findSelfOrElement(node *html.Node, name string) *html.Node {
+	if node.Type == html.ElementNode && strings.ToLower(node.Data) == name {
+		return node
+	}
+	return findElement(node, name)
+}
+
+// Calls fn on the descendants of node in document order, the descendants of the nodes for which fn returns false are skipped.
+// The tree is visited without recursion: the stack holds the next node to visit at each level.
+func walkNodes(node *html.Node, fn func(*html.Node) bool) {
+	stack := []*html.Node{node.FirstChild}
+	for len(stack) > 0 {
+		top := len(stack) - 1
+		child := stack[top]
+		if child == nil {
+			stack = stack[:top]
+			continue
+		}
+		stack[top] = child.NextSibling
+		if fn(child) && child.FirstChild != nil {
+			stack = append(stack, child.FirstChild)
+		}
+	}
+}
+
+// Calls fn on e and its descendants in pre-order, skipping nil children.
+// The tree is visited without recursion.
+func walkElements(e *element, fn func(*element)) {
+	if e == nil {
+		return
+	}
+	stack := []*element{e}
+	for len(stack) > 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		fn(e)
+		for i := len(e.childs) - 1; i >= 0; i-- {
+			if e.childs[i] != nil {
+				stack = append(stack, e.childs[i])
+			}
+		}
+	}
+}
+
+// Returns the content of the first meta element whose property or name attribute is one of names
+func getMeta(root *html.Node, names ...string) string {
+	content := ""
+	forEachElement(root, "meta", func(meta *html.Node) {
+		if content != "" {
+			return
+		}
+		property := strings.ToLower(getAttribute(meta, "property"))
+		metaName := strings.ToLower(getAttribute(meta, "name"))
+		for _, name := range names {
+			if property == name || metaName == name {
+				content = strings.TrimSpace(getAttribute(meta, "content"))
+				return
+			}
+		}
+	})
+	return content
+}
@@ -1,6 +1,8 @@
 package sandblast
 
 import (
+	"golang.org/x/net/html"
+	"strings"
 	"testing"
 )
 
@@ -34,3 +36,82 @@ func TestCleanAsciiArt(t *testing.T) {
 	tf("test ===== test === test", "test  test === test")
 	tf("saw this exact same trick performed in a public bar OVER FORTY YEARS AGO. pretty good then; old hat now.", "saw this exact same trick performed in a public bar OVER FORTY YEARS AGO. pretty good then; old hat now.")
 }
+
+func TestWalkNodes(t *testing.T) {
+	node, err := html.Parse(strings.NewReader("<html><head><meta property='og:title' content='Meta title'></head><body><div>a<span>b</span><p>c<script>d</script></p>e</div><h1>The heading</h1></body></html>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// deeper than a recursive walk would comfortably handle
+	parent := findElement(node, "body")
+	for i := 0; i < 100000; i++ {
+		div := &html.Node{Type: html.ElementNode, Data: "div"}
+		parent.AppendChild(div)
+		parent = div
+	}
+	parent.AppendChild(&html.Node{Type: html.ElementNode, Data: "h2", Attr: []html.Attribute{{Key: "class", Val: "credit"}, {Key: "id", Val: "deep"}}})
+
+	if out := nodeText(findElement(node, "div"), nil); out != "a b c e" {
+		t.Errorf("Error getting text\n\tgot <%s>\n\texpected <%s>\n", out, "a b c e")
+	}
+	if findElement(node, "h2") != parent.FirstChild || findElement(node, "h1") == nil || findElement(node, "table") != nil {
+		t.Errorf("Error finding elements")
+	}
+	if out := getMeta(node, "og:title"); out != "Meta title" {
+		t.Errorf("Error getting meta\n\tgot <%s>\n\texpected <%s>\n", out, "Meta title")
+	}
+	n := 0
+	forEachElement(node, "div", func(*html.Node) { n++ })
+	if n != 100001 {
+		t.Errorf("Wrong number of elements visited: %d", n)
+	}
+	if out := findId(findElement(node, "body")); out != "deep" {
+		t.Errorf("Error finding id\n\tgot <%s>\n\texpected <%s>\n", out, "deep")
+	}
+	if findCredit(node) != parent.FirstChild {
+		t.Errorf("Error finding credit")
+	}
+}
+
+func TestGetMeta(t *testing.T) {
+	tf := func(doc string, names []string, target string) {
+		node, err := html.Parse(strings.NewReader(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out := getMeta(node, names...); out != target {
+			t.Errorf("Error getting meta %v of <%s>\n\tgot <%s>\n\texpected <%s>\n", names, doc, out, target)
+		}
+	}
+	tf("<html><head><meta property='og:description' content='A'></head></html>", []string{"og:description"}, "A")
+	tf("<html><head><meta name='Description' content='A'></head></html>", []string{"description"}, "A")
+	tf("<html><head><meta property='og:description' name='description' content='A'></head></html>", []string{"description"}, "A")
+	tf("<html><head><meta property='og:description' name='description' content='A'></head></html>", []string{"og:description"}, "A")
+	tf("<html><head><meta name='keywords' content='A'></head></html>", []string{"description"}, "")
+}
+
+func TestWalkElements(t *testing.T) {
+	// the debug string is indented by depth, so the tree is not as deep as in TestWalkNodes
+	root := &element{tag: "~transient"}
+	e := root
+	for i := 0; i < 5000; i++ {
+		child := &element{tag: "div", sources: []*html.Node{{Type: html.ElementNode, Data: "div"}}}
+		e.childs = []*element{nil, child}
+		e = child
+	}
+	e.childs = []*element{{tag: "~figure", figure: &Figure{Src: "a.png"}}, {tag: "~media", media: &Media{URL: "a.mp4"}}}
+
+	if n := len(root.allSources()); n != 5000 {
+		t.Errorf("Wrong number of sources: %d", n)
+	}
+	if figures := collectFigures(root); len(figures) != 1 || figures[0].Src != "a.png" {
+		t.Errorf("Error collecting figures: %v", figures)
+	}
+	if media := collectMedia(root); len(media) != 1 || media[0].URL != "a.mp4" {
+		t.Errorf("Error collecting media: %v", media)
+	}
+	if out := root.DebugString(); strings.Count(out, "<nil>") != 5000 || !strings.Contains(out, ":img=a.png") {
+		t.Errorf("Error writing the debug string")
+	}
+}